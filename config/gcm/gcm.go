@@ -0,0 +1,8 @@
+package gcm
+
+import "embed"
+
+//go:generate go run ../.. schema --templates-dir state_cluster/index_templates --watches-dir state_cluster/watches
+
+//go:embed state_cluster
+var StateCluster embed.FS
@@ -0,0 +1,237 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/metrics"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+type firingAlert struct {
+	startedOn time.Time
+
+	// notifiedOn holds when each notifier last received the alert
+	notifiedOn map[string]time.Time
+}
+
+// Alerter turns validation results into alerts and sends them to the
+// scenarios' notifiers. Delivery is tracked per notifier.
+type Alerter struct {
+	notifiers        map[string]Notifier
+	defaultNotifiers []string
+	repeatInterval   time.Duration
+
+	mu sync.Mutex
+	// firing maps scenario IDs to their firing alerts, by metric
+	firing map[string]map[string]*firingAlert
+}
+
+func NewAlerter(cfg *config.Config) (*Alerter, error) {
+	notifiers, err := NewNotifiers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	a := new(Alerter)
+	a.notifiers = notifiers
+	a.defaultNotifiers = cfg.Alerting.DefaultNotifiers
+	a.repeatInterval = cfg.GetAlertRepeatInterval()
+	a.firing = map[string]map[string]*firingAlert{}
+
+	return a, nil
+}
+
+func (a *Alerter) Validate(alerts *models.Alerts) error {
+	if alerts == nil {
+		return nil
+	}
+
+	for _, name := range alerts.Notifiers {
+		if _, ok := a.notifiers[name]; !ok {
+			return fmt.Errorf("notifier [%s] is not configured", name)
+		}
+	}
+
+	return nil
+}
+
+func (a *Alerter) Observe(s *models.Scenario, result *models.ValidationResult) {
+	if s.Alerts != nil && s.Alerts.Disabled {
+		return
+	}
+
+	notifiers := a.route(s)
+	if len(notifiers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	for _, notifier := range notifiers {
+		alerts := a.evaluate(s, result, notifier.Name())
+		if len(alerts) == 0 {
+			continue
+		}
+
+		err := notifier.Notify(ctx, alerts)
+		metrics.ObserveNotification(notifier.Name(), err)
+		if err != nil {
+			logging.Logger.Error("unable to send alerts",
+				zap.String("scenario", s.ID),
+				zap.String("notifier", notifier.Name()),
+				zap.Error(err),
+			)
+			continue
+		}
+		a.commit(s.ID, notifier.Name(), alerts, result.ValidatedOn)
+	}
+
+	a.settle(s.ID, result)
+}
+
+func (a *Alerter) Forget(scenarioID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.firing, scenarioID)
+}
+
+func (a *Alerter) route(s *models.Scenario) []Notifier {
+	names := a.defaultNotifiers
+	if s.Alerts != nil && len(s.Alerts.Notifiers) > 0 {
+		names = s.Alerts.Notifiers
+	}
+
+	if len(names) == 0 {
+		for name := range a.notifiers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	notifiers := make([]Notifier, 0, len(names))
+	for _, name := range names {
+		notifier, ok := a.notifiers[name]
+		if !ok {
+			logging.Logger.Warn("scenario routes alerts to unknown notifier",
+				zap.String("scenario", s.ID),
+				zap.String("notifier", name),
+			)
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	return notifiers
+}
+
+func (a *Alerter) evaluate(s *models.Scenario, result *models.ValidationResult, notifierName string) []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	repeatInterval := s.Alerts.GetRepeatInterval(a.repeatInterval)
+	firing := a.firing[s.ID]
+	if firing == nil {
+		firing = map[string]*firingAlert{}
+		a.firing[s.ID] = firing
+	}
+
+	metricResults := result.Metrics()
+	names := make([]string, 0, len(metricResults))
+	for name := range metricResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var alerts []Alert
+	for _, name := range names {
+		r := metricResults[name]
+		if r.Error != "" {
+			continue
+		}
+
+		f, isFiring := firing[name]
+		if !r.IsValid && !isFiring {
+			f = &firingAlert{
+				startedOn:  result.ValidatedOn,
+				notifiedOn: map[string]time.Time{},
+			}
+			firing[name] = f
+		}
+		if f == nil {
+			continue
+		}
+		notifiedOn, isNotified := f.notifiedOn[notifierName]
+
+		alert := Alert{
+			ScenarioID:   s.ID,
+			DeploymentID: s.DeploymentID,
+			Metric:       name,
+			Actual:       r.Actual,
+			Expected:     r.Expected,
+			StartedOn:    f.startedOn,
+			ValidatedOn:  result.ValidatedOn,
+		}
+
+		switch {
+		case !r.IsValid && !isNotified:
+			alert.Status = StatusFiring
+		case !r.IsValid && result.ValidatedOn.Sub(notifiedOn) >= repeatInterval:
+			alert.Status = StatusFiring
+		case r.IsValid && isNotified:
+			alert.Status = StatusResolved
+		default:
+			continue
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts
+}
+
+func (a *Alerter) commit(scenarioID, notifierName string, alerts []Alert, notifiedOn time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	firing := a.firing[scenarioID]
+	for _, alert := range alerts {
+		f, ok := firing[alert.Metric]
+		if !ok {
+			continue
+		}
+
+		switch alert.Status {
+		case StatusFiring:
+			f.notifiedOn[notifierName] = notifiedOn
+		case StatusResolved:
+			delete(f.notifiedOn, notifierName)
+		}
+	}
+}
+
+func (a *Alerter) settle(scenarioID string, result *models.ValidationResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	firing := a.firing[scenarioID]
+	for name, r := range result.Metrics() {
+		f, ok := firing[name]
+		if ok && r.Error == "" && r.IsValid && len(f.notifiedOn) == 0 {
+			delete(firing, name)
+		}
+	}
+
+	if len(firing) == 0 {
+		delete(a.firing, scenarioID)
+	}
+}
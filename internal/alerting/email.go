@@ -0,0 +1,89 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSMTPPort = 587
+
+// Email sends alerts by email through an SMTP server. STARTTLS is used if the
+// server supports it.
+type Email struct {
+	name string
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func NewEmail(name, host string, port int, username, password, from string, to []string) *Email {
+	if port <= 0 {
+		port = defaultSMTPPort
+	}
+
+	e := new(Email)
+	e.name = name
+	e.addr = net.JoinHostPort(host, strconv.Itoa(port))
+	e.from = from
+	e.to = to
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return e
+}
+
+func (e *Email) Name() string {
+	return e.name
+}
+
+func (e *Email) Notify(ctx context.Context, alerts []Alert) error {
+	var body strings.Builder
+	for _, alert := range alerts {
+		fmt.Fprintf(&body, "%s\r\n", alert.Summary())
+		fmt.Fprintf(&body, "  deployment: %s\r\n", alert.DeploymentID)
+		fmt.Fprintf(&body, "  failing since: %s\r\n", alert.StartedOn.Format(time.RFC3339))
+		fmt.Fprintf(&body, "  validated on: %s\r\n\r\n", alert.ValidatedOn.Format(time.RFC3339))
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		e.from, strings.Join(e.to, ", "), subject(alerts), time.Now().Format(time.RFC1123Z), body.String())
+
+	// net/smtp does not support contexts, so the context only bounds the wait
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(e.addr, e.auth, e.from, e.to, []byte(msg))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("unable to send alerts: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("unable to send alerts: %w", ctx.Err())
+	}
+}
+
+func subject(alerts []Alert) string {
+	firing := 0
+	for _, alert := range alerts {
+		if alert.Status == StatusFiring {
+			firing++
+		}
+	}
+
+	scenarioID := alerts[0].ScenarioID
+	if firing == 0 {
+		return fmt.Sprintf("[RESOLVED] scenario [%s] passes validation", scenarioID)
+	}
+
+	return fmt.Sprintf("[FIRING] scenario [%s] fails validation of %d metric(s)", scenarioID, firing)
+}
@@ -0,0 +1,82 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const notifyTimeout = 30 * time.Second
+
+type Status string
+
+const (
+	StatusFiring Status = "firing"
+
+	StatusResolved Status = "resolved"
+)
+
+type Alert struct {
+	Status       Status            `json:"status"`
+	ScenarioID   string            `json:"scenario_id"`
+	DeploymentID string            `json:"deployment_id"`
+	Metric       string            `json:"metric"`
+	Actual       float64           `json:"actual"`
+	Expected     models.FloatRange `json:"expected"`
+
+	StartedOn   time.Time `json:"started_on"`
+	ValidatedOn time.Time `json:"validated_on"`
+}
+
+func (a Alert) Summary() string {
+	return fmt.Sprintf("[%s] scenario [%s]: %s is %g, expected between %g and %g",
+		strings.ToUpper(string(a.Status)), a.ScenarioID, a.Metric, a.Actual, a.Expected.Min, a.Expected.Max)
+}
+
+type Notifier interface {
+	Name() string
+
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+func NewNotifiers(cfg *config.Config) (map[string]Notifier, error) {
+	notifiers := make(map[string]Notifier, len(cfg.Alerting.Notifiers))
+	client := &http.Client{Timeout: notifyTimeout}
+
+	for _, n := range cfg.Alerting.Notifiers {
+		var notifier Notifier
+		switch n.Type {
+		case config.NotifierTypeWebhook, config.NotifierTypeSlack:
+			url, err := config.ReadSecret(n.URL, n.URLFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read URL of notifier [%s]: %w", n.Name, err)
+			}
+
+			if n.Type == config.NotifierTypeSlack {
+				notifier = NewSlack(n.Name, url, client)
+			} else {
+				notifier = NewWebhook(n.Name, url, n.Headers, client)
+			}
+
+		case config.NotifierTypeEmail:
+			password, err := config.ReadSecret(n.SMTP.Password, n.SMTP.PasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read SMTP password of notifier [%s]: %w", n.Name, err)
+			}
+
+			notifier = NewEmail(n.Name, n.SMTP.Host, n.SMTP.Port, n.SMTP.Username, password, n.SMTP.From, n.SMTP.To)
+
+		default:
+			return nil, fmt.Errorf("notifier [%s] has unknown type [%s]", n.Name, n.Type)
+		}
+
+		notifiers[n.Name] = notifier
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,38 @@
+package alerting
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Slack posts alerts as a message to a Slack incoming webhook, or any webhook
+// accepting Slack's message format.
+type Slack struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func NewSlack(name, url string, client *http.Client) *Slack {
+	return &Slack{
+		name:   name,
+		url:    url,
+		client: client,
+	}
+}
+
+func (s *Slack) Name() string {
+	return s.name
+}
+
+func (s *Slack) Notify(ctx context.Context, alerts []Alert) error {
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		lines = append(lines, alert.Summary())
+	}
+
+	return postJSON(ctx, s.client, s.url, nil, map[string]string{
+		"text": strings.Join(lines, "\n"),
+	})
+}
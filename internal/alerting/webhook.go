@@ -0,0 +1,66 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Webhook posts alerts as JSON to a URL:
+//
+//	{"alerts": [{"status": "firing", "scenario_id": "...", "metric": "...", ...}]}
+type Webhook struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhook(name, url string, headers map[string]string, client *http.Client) *Webhook {
+	return &Webhook{
+		name:    name,
+		url:     url,
+		headers: headers,
+		client:  client,
+	}
+}
+
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+func (w *Webhook) Notify(ctx context.Context, alerts []Alert) error {
+	return postJSON(ctx, w.client, w.url, w.headers, map[string]interface{}{
+		"alerts": alerts,
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("unable to encode alerts as JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send alerts: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unable to send alerts: [%s]", res.Status)
+	}
+
+	return nil
+}
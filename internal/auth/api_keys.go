@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+const stateIndices = "gds-*"
+
+// Invalidated API keys may be used until their cache entry expires
+const apiKeyCacheTTL = time.Minute
+
+type cachedPrincipal struct {
+	principal *Principal
+	expiresOn time.Time
+}
+
+type APIKeys struct {
+	stateConn *es.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedPrincipal
+}
+
+func NewAPIKeys(stateConn *es.Client) *APIKeys {
+	ak := new(APIKeys)
+	ak.stateConn = stateConn
+	ak.cache = map[[sha256.Size]byte]cachedPrincipal{}
+
+	return ak
+}
+
+func (ak *APIKeys) Scheme() string {
+	return "ApiKey"
+}
+
+func (ak *APIKeys) Authenticate(credentials string) (*Principal, error) {
+	key := sha256.Sum256([]byte(credentials))
+	now := time.Now()
+
+	ak.mu.Lock()
+	cached, ok := ak.cache[key]
+	ak.mu.Unlock()
+	if ok && now.Before(cached.expiresOn) {
+		return cached.principal, nil
+	}
+
+	principal, err := ak.authenticate(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	ak.mu.Lock()
+	defer ak.mu.Unlock()
+
+	for k, c := range ak.cache {
+		if !now.Before(c.expiresOn) {
+			delete(ak.cache, k)
+		}
+	}
+	ak.cache[key] = cachedPrincipal{principal: principal, expiresOn: now.Add(apiKeyCacheTTL)}
+
+	return principal, nil
+}
+
+func (ak *APIKeys) authenticate(credentials string) (*Principal, error) {
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	keyID := strings.SplitN(string(decoded), ":", 2)[0]
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"index": []map[string]interface{}{
+			{
+				"names":      []string{stateIndices},
+				"privileges": []string{"read", "write"},
+			},
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("unable to encode privileges as JSON: %w", err)
+	}
+
+	res, err := ak.stateConn.Security.HasPrivileges(
+		&buf,
+		ak.stateConn.Security.HasPrivileges.WithHeader(map[string]string{
+			"Authorization": "ApiKey " + credentials,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check privileges of API key [%s]: %w", keyID, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthenticated
+	}
+
+	if res.IsError() {
+		return nil, fmt.Errorf("unable to check privileges of API key [%s]: %w", keyID, esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Username string                     `json:"username"`
+		Index    map[string]map[string]bool `json:"index"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	principal := &Principal{
+		Name:   fmt.Sprintf("%s/%s", r.Username, keyID),
+		Method: "api_key",
+	}
+
+	privileges := r.Index[stateIndices]
+	switch {
+	case privileges["write"]:
+		principal.Role = RoleOperator
+	case privileges["read"]:
+		principal.Role = RoleViewer
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+)
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+
+	RoleOperator Role = "operator"
+)
+
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+}
+
+func (r Role) Allows(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("unknown role [%s]", s)
+	}
+
+	return role, nil
+}
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+	ErrForbidden = errors.New("role does not allow this request")
+)
+
+type Principal struct {
+	Name   string
+	Role   Role
+	Method string
+}
+
+var Anonymous = &Principal{
+	Name:   "anonymous",
+	Role:   RoleOperator,
+	Method: "none",
+}
+
+type Authenticator interface {
+	Scheme() string
+
+	Authenticate(credentials string) (*Principal, error)
+}
+
+func NewAuthenticators(cfg *config.Config) (map[string]Authenticator, error) {
+	authenticators := map[string]Authenticator{}
+
+	if len(cfg.Auth.Tokens) > 0 {
+		tokens, err := NewStaticTokens(cfg.Auth.Tokens)
+		if err != nil {
+			return nil, err
+		}
+		authenticators[tokens.Scheme()] = tokens
+	}
+
+	if cfg.Auth.ElasticsearchAPIKeys {
+		stateConn, err := dao.NewStateClusterConnection(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		apiKeys := NewAPIKeys(stateConn)
+		authenticators[apiKeys.Scheme()] = apiKeys
+	}
+
+	return authenticators, nil
+}
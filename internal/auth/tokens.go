@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+)
+
+// StaticTokens authenticates bearer tokens set in the configuration.
+type StaticTokens struct {
+	// Tokens are looked up by their hash, which takes the same time whether or
+	// not they match.
+	principals map[[sha256.Size]byte]*Principal
+}
+
+func NewStaticTokens(tokens []config.AuthToken) (*StaticTokens, error) {
+	st := new(StaticTokens)
+	st.principals = make(map[[sha256.Size]byte]*Principal, len(tokens))
+
+	for _, t := range tokens {
+		role, err := ParseRole(t.Role)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth token [%s]: %w", t.Name, err)
+		}
+
+		token, err := config.ReadSecret(t.Token, t.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read auth token [%s]: %w", t.Name, err)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("invalid auth token [%s]: token is empty", t.Name)
+		}
+
+		st.principals[sha256.Sum256([]byte(token))] = &Principal{
+			Name:   t.Name,
+			Role:   role,
+			Method: "token",
+		}
+	}
+
+	return st, nil
+}
+
+func (st *StaticTokens) Scheme() string {
+	return "Bearer"
+}
+
+func (st *StaticTokens) Authenticate(credentials string) (*Principal, error) {
+	principal, ok := st.principals[sha256.Sum256([]byte(credentials))]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,80 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+type AssetType string
+
+const (
+	AssetTypeILMPolicy     AssetType = "ilm_policy"
+	AssetTypeIndexTemplate AssetType = "index_template"
+	AssetTypeWatch         AssetType = "watch"
+)
+
+// assetDirs are in the order in which assets are applied
+var assetDirs = []struct {
+	dir       string
+	assetType AssetType
+}{
+	{"ilm_policies", AssetTypeILMPolicy},
+	{"index_templates", AssetTypeIndexTemplate},
+	{"watches", AssetTypeWatch},
+}
+
+type Asset struct {
+	Type AssetType
+	Name string
+	Body []byte
+}
+
+func (a Asset) Key() string {
+	return fmt.Sprintf("%s/%s", a.Type, a.Name)
+}
+
+func (a Asset) Hash() string {
+	return models.Hash(a.Body)
+}
+
+func LoadAssets(fsys fs.FS) ([]Asset, error) {
+	var assets []Asset
+	for _, d := range assetDirs {
+		entries, err := fs.ReadDir(fsys, d.dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to list assets in [%s]: %w", d.dir, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+				continue
+			}
+
+			filePath := path.Join(d.dir, entry.Name())
+			body, err := fs.ReadFile(fsys, filePath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read asset [%s]: %w", filePath, err)
+			}
+			if !json.Valid(body) {
+				return nil, fmt.Errorf("asset [%s] is not valid JSON", filePath)
+			}
+
+			assets = append(assets, Asset{
+				Type: d.assetType,
+				Name: strings.TrimSuffix(entry.Name(), ".json"),
+				Body: body,
+			})
+		}
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,309 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/schema"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/watches"
+)
+
+const stampsIndex = "gds-bootstrap-assets"
+
+type stamp struct {
+	Type      AssetType `json:"type"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	AppliedOn time.Time `json:"applied_on"`
+}
+
+type Bootstrapper struct {
+	stateConn *es.Client
+	assets    []Asset
+
+	obsolete []Asset
+}
+
+func New(stateConn *es.Client, fsys fs.FS, cfg *config.Config) (*Bootstrapper, error) {
+	loaded, err := LoadAssets(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]Asset, 0, len(loaded))
+	var obsolete []Asset
+	for _, asset := range loaded {
+		switch {
+		case asset.Type == AssetTypeIndexTemplate:
+			// Mappings are derived from the models
+			asset.Body, err = schema.RenderIndexTemplate(asset.Name, asset.Body)
+			if err != nil {
+				return nil, err
+			}
+
+		case asset.Type == AssetTypeWatch && asset.Name == watches.ValidationFailures:
+			// The scenario runner installs a watch for each scenario instead
+			if cfg.Watches.ValidationFailures.PerScenario {
+				obsolete = append(obsolete, asset)
+				continue
+			}
+
+			asset.Body, err = watches.RenderValidationFailures(cfg.Watches.ValidationFailures, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		assets = append(assets, asset)
+	}
+
+	b := new(Bootstrapper)
+	b.stateConn = stateConn
+	b.assets = assets
+	b.obsolete = obsolete
+
+	return b, nil
+}
+
+// Apply installs the assets that are missing or changed, and adds missing
+// fields to the mappings of existing indices. Incompatible indices are only
+// migrated if migrate is set.
+func (b *Bootstrapper) Apply(migrate bool) error {
+	stamps, err := b.getStamps()
+	if err != nil {
+		return err
+	}
+
+	var applied []Asset
+	for _, asset := range b.assets {
+		loggingParam := zap.String("asset", asset.Key())
+
+		exists, err := b.exists(asset)
+		if err != nil {
+			return err
+		}
+
+		if exists && stamps[asset.Key()] == asset.Hash() {
+			logging.Logger.Debug("asset is up to date", loggingParam)
+			continue
+		}
+
+		if err := b.put(asset); err != nil {
+			return err
+		}
+		applied = append(applied, asset)
+		logging.Logger.Info("applied asset", loggingParam, zap.String("hash", asset.Hash()))
+	}
+
+	// The stamps index is created from its index template
+	for _, asset := range applied {
+		if err := b.putStamp(asset); err != nil {
+			return err
+		}
+	}
+
+	for _, asset := range b.obsolete {
+		deleted, err := b.delete(asset)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			logging.Logger.Info("deleted obsolete asset", zap.String("asset", asset.Key()))
+		}
+	}
+
+	drifts, err := b.Drift()
+	if err != nil {
+		return err
+	}
+	if !migrate {
+		if err := incompatible(drifts); err != nil {
+			return err
+		}
+	}
+
+	for _, drift := range drifts {
+		if !drift.IsCompatible() {
+			if err := b.migrate(drift); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := b.updateMapping(drift); err != nil {
+			return err
+		}
+		logging.Logger.Info("added missing fields to index mapping",
+			zap.String("index", drift.Index),
+			zap.Strings("fields", drift.Missing),
+		)
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) exists(asset Asset) (bool, error) {
+	var (
+		res *esapi.Response
+		err error
+	)
+	switch asset.Type {
+	case AssetTypeILMPolicy:
+		res, err = b.stateConn.ILM.GetLifecycle(b.stateConn.ILM.GetLifecycle.WithPolicy(asset.Name))
+	case AssetTypeIndexTemplate:
+		res, err = b.stateConn.Indices.ExistsIndexTemplate(asset.Name)
+	case AssetTypeWatch:
+		res, err = b.stateConn.Watcher.GetWatch(asset.Name)
+	default:
+		return false, fmt.Errorf("unknown asset type [%s]", asset.Type)
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to check if asset [%s] exists: %w", asset.Key(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if res.IsError() {
+		return false, esutil.ResponseError(res)
+	}
+
+	return true, nil
+}
+
+func (b *Bootstrapper) put(asset Asset) error {
+	var (
+		res *esapi.Response
+		err error
+	)
+	body := bytes.NewReader(asset.Body)
+	switch asset.Type {
+	case AssetTypeILMPolicy:
+		res, err = b.stateConn.ILM.PutLifecycle(asset.Name, b.stateConn.ILM.PutLifecycle.WithBody(body))
+	case AssetTypeIndexTemplate:
+		res, err = b.stateConn.Indices.PutIndexTemplate(asset.Name, body)
+	case AssetTypeWatch:
+		res, err = b.stateConn.Watcher.PutWatch(asset.Name, b.stateConn.Watcher.PutWatch.WithBody(body))
+	default:
+		return fmt.Errorf("unknown asset type [%s]", asset.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to apply asset [%s]: %w", asset.Key(), err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to apply asset [%s]: %w", asset.Key(), esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) delete(asset Asset) (bool, error) {
+	var (
+		res *esapi.Response
+		err error
+	)
+	switch asset.Type {
+	case AssetTypeILMPolicy:
+		res, err = b.stateConn.ILM.DeleteLifecycle(asset.Name)
+	case AssetTypeIndexTemplate:
+		res, err = b.stateConn.Indices.DeleteIndexTemplate(asset.Name)
+	case AssetTypeWatch:
+		res, err = b.stateConn.Watcher.DeleteWatch(asset.Name)
+	default:
+		return false, fmt.Errorf("unknown asset type [%s]", asset.Type)
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to delete asset [%s]: %w", asset.Key(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if res.IsError() {
+		return false, fmt.Errorf("unable to delete asset [%s]: %w", asset.Key(), esutil.ResponseError(res))
+	}
+
+	return true, nil
+}
+
+func (b *Bootstrapper) getStamps() (map[string]string, error) {
+	res, err := b.stateConn.Search(
+		b.stateConn.Search.WithIndex(stampsIndex),
+		b.stateConn.Search.WithSize(1000),
+		b.stateConn.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get asset stamps: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, esutil.ResponseError(res)
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Source stamp `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	stamps := map[string]string{}
+	for _, hit := range r.Hits.Hits {
+		asset := Asset{Type: hit.Source.Type, Name: hit.Source.Name}
+		stamps[asset.Key()] = hit.Source.Hash
+	}
+
+	return stamps, nil
+}
+
+func (b *Bootstrapper) putStamp(asset Asset) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(stamp{
+		Type:      asset.Type,
+		Name:      asset.Name,
+		Hash:      asset.Hash(),
+		AppliedOn: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("unable to encode stamp of asset [%s] as JSON: %w", asset.Key(), err)
+	}
+
+	res, err := b.stateConn.Index(
+		stampsIndex,
+		&buf,
+		b.stateConn.Index.WithDocumentID(strings.ReplaceAll(asset.Key(), "/", ":")),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to persist stamp of asset [%s]: %w", asset.Key(), err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+type MappingDrift struct {
+	Index    string
+	Template string
+
+	DataStream string
+
+	Missing []string
+
+	Conflicting []string
+
+	// name is the alias of the index once it has been migrated
+	name     string
+	settings map[string]interface{}
+	mappings map[string]interface{}
+}
+
+func (d MappingDrift) IsCompatible() bool {
+	return len(d.Conflicting) == 0
+}
+
+type templateBody struct {
+	IndexPatterns []string        `json:"index_patterns"`
+	DataStream    json.RawMessage `json:"data_stream"`
+	Template      struct {
+		Settings map[string]interface{} `json:"settings"`
+		Mappings map[string]interface{} `json:"mappings"`
+	} `json:"template"`
+}
+
+// Drift returns the indices whose mappings differ from their index templates.
+// Only the write indices of data streams are compared.
+func (b *Bootstrapper) Drift() ([]MappingDrift, error) {
+	var drifts []MappingDrift
+	for _, asset := range b.assets {
+		if asset.Type != AssetTypeIndexTemplate {
+			continue
+		}
+
+		var tpl templateBody
+		if err := json.Unmarshal(asset.Body, &tpl); err != nil {
+			return nil, fmt.Errorf("unable to parse index template [%s]: %w", asset.Name, err)
+		}
+		if tpl.Template.Mappings == nil || len(tpl.IndexPatterns) == 0 {
+			continue
+		}
+
+		indices := tpl.IndexPatterns
+		var dataStreams map[string]string
+		if tpl.DataStream != nil {
+			var err error
+			dataStreams, err = b.getWriteIndices(tpl.IndexPatterns)
+			if err != nil {
+				return nil, err
+			}
+
+			indices = make([]string, 0, len(dataStreams))
+			for index := range dataStreams {
+				indices = append(indices, index)
+			}
+			if len(indices) == 0 {
+				continue
+			}
+		}
+
+		liveMappings, err := b.getMappings(indices)
+		if err != nil {
+			return nil, err
+		}
+
+		expected := flattenMapping(tpl.Template.Mappings)
+		for index, mappings := range liveMappings {
+			drift := MappingDrift{
+				Index:      index,
+				Template:   asset.Name,
+				DataStream: dataStreams[index],
+				name:       indexName(index, tpl.IndexPatterns),
+				settings:   tpl.Template.Settings,
+				mappings:   tpl.Template.Mappings,
+			}
+
+			actual := flattenMapping(mappings)
+			for field, expectedType := range expected {
+				actualType, mapped := actual[field]
+				switch {
+				case !mapped:
+					drift.Missing = append(drift.Missing, field)
+				case actualType != expectedType:
+					drift.Conflicting = append(drift.Conflicting,
+						fmt.Sprintf("%s (%s instead of %s)", field, actualType, expectedType))
+				}
+			}
+
+			if len(drift.Missing) > 0 || len(drift.Conflicting) > 0 {
+				sort.Strings(drift.Missing)
+				sort.Strings(drift.Conflicting)
+				drifts = append(drifts, drift)
+			}
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Index < drifts[j].Index
+	})
+
+	return drifts, nil
+}
+
+func (b *Bootstrapper) CheckMappings() ([]MappingDrift, error) {
+	drifts, err := b.Drift()
+	if err != nil {
+		return nil, err
+	}
+
+	return drifts, incompatible(drifts)
+}
+
+func incompatible(drifts []MappingDrift) error {
+	var msgs []string
+	for _, drift := range drifts {
+		if !drift.IsCompatible() {
+			msgs = append(msgs, fmt.Sprintf("index [%s] conflicts with index template [%s] on fields [%s]",
+				drift.Index, drift.Template, strings.Join(drift.Conflicting, ", ")))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("incompatible index mappings, run `ecbgd bootstrap --migrate`: %s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+func indexName(index string, indexPatterns []string) string {
+	for _, pattern := range indexPatterns {
+		if strings.HasPrefix(index, pattern+"-") {
+			return pattern
+		}
+	}
+
+	return index
+}
+
+func (b *Bootstrapper) getMappings(indexPatterns []string) (map[string]map[string]interface{}, error) {
+	res, err := b.stateConn.Indices.GetMapping(
+		b.stateConn.Indices.GetMapping.WithIndex(indexPatterns...),
+		b.stateConn.Indices.GetMapping.WithIgnoreUnavailable(true),
+		b.stateConn.Indices.GetMapping.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get mappings of indices [%s]: %w", strings.Join(indexPatterns, ","), err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, esutil.ResponseError(res)
+	}
+
+	var r map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	mappings := make(map[string]map[string]interface{}, len(r))
+	for index, m := range r {
+		mappings[index] = m.Mappings
+	}
+
+	return mappings, nil
+}
+
+func (b *Bootstrapper) getWriteIndices(patterns []string) (map[string]string, error) {
+	res, err := b.stateConn.Indices.GetDataStream(
+		b.stateConn.Indices.GetDataStream.WithName(patterns...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get data streams [%s]: %w", strings.Join(patterns, ","), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+
+	if res.IsError() {
+		return nil, esutil.ResponseError(res)
+	}
+
+	var r struct {
+		DataStreams []struct {
+			Name    string `json:"name"`
+			Indices []struct {
+				IndexName string `json:"index_name"`
+			} `json:"indices"`
+		} `json:"data_streams"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	writeIndices := map[string]string{}
+	for _, ds := range r.DataStreams {
+		if len(ds.Indices) > 0 {
+			writeIndices[ds.Indices[len(ds.Indices)-1].IndexName] = ds.Name
+		}
+	}
+
+	return writeIndices, nil
+}
+
+func (b *Bootstrapper) updateMapping(drift MappingDrift) error {
+	if len(drift.Missing) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(drift.mappings); err != nil {
+		return fmt.Errorf("unable to encode mapping of index template [%s] as JSON: %w", drift.Template, err)
+	}
+
+	res, err := b.stateConn.Indices.PutMapping(
+		&buf,
+		b.stateConn.Indices.PutMapping.WithIndex(drift.Index),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update mapping of index [%s]: %w", drift.Index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to update mapping of index [%s]: %w", drift.Index, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func flattenMapping(mapping map[string]interface{}) map[string]string {
+	fields := map[string]string{}
+	flattenProperties("", mapping, fields)
+
+	return fields
+}
+
+func flattenProperties(prefix string, mapping map[string]interface{}, fields map[string]string) {
+	properties, ok := mapping["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, p := range properties {
+		property, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path := prefix + name
+		fieldType, ok := property["type"].(string)
+		if !ok {
+			fieldType = "object"
+		}
+		fields[path] = fieldType
+
+		flattenProperties(path+".", property, fields)
+	}
+}
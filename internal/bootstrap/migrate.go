@@ -0,0 +1,297 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+)
+
+const leasesIndex = "gds-scenario-leases"
+
+// migrate rolls over data streams, and copies other indices into a new index
+// that replaces them behind an alias. Writes to the old index are blocked while
+// it is copied, and it is only deleted once the new index holds all of its
+// documents.
+func (b *Bootstrapper) migrate(drift MappingDrift) error {
+	logging.Logger.Info("migrating index",
+		zap.String("index", drift.Index),
+		zap.Strings("conflicting_fields", drift.Conflicting),
+	)
+
+	if drift.DataStream != "" {
+		return b.rollover(drift.DataStream)
+	}
+
+	held, err := b.heldLeases()
+	if err != nil {
+		return err
+	}
+	if len(held) > 0 {
+		return fmt.Errorf("unable to migrate index [%s] while scenarios [%s] are leased, stop all servers first",
+			drift.Index, strings.Join(held, ", "))
+	}
+
+	newIndex := nextIndex(drift.name, drift.Index)
+	if err := b.deleteIndex(newIndex); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]interface{}{
+		"settings": drift.settings,
+		"mappings": drift.mappings,
+	}); err != nil {
+		return fmt.Errorf("unable to encode index template [%s] as JSON: %w", drift.Template, err)
+	}
+
+	res, err := b.stateConn.Indices.Create(newIndex, b.stateConn.Indices.Create.WithBody(&body))
+	if err != nil {
+		return fmt.Errorf("unable to create index [%s]: %w", newIndex, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to create index [%s]: %w", newIndex, esutil.ResponseError(res))
+	}
+
+	if err := b.blockWrites(drift.Index, true); err != nil {
+		return err
+	}
+	if err := b.copyIndex(drift.Index, newIndex); err != nil {
+		if unblockErr := b.blockWrites(drift.Index, false); unblockErr != nil {
+			logging.Logger.Error("unable to unblock writes to index", zap.String("index", drift.Index), zap.Error(unblockErr))
+		}
+		return err
+	}
+
+	return b.replaceIndex(drift.name, drift.Index, newIndex)
+}
+
+func (b *Bootstrapper) copyIndex(source, dest string) error {
+	if err := b.reindex(source, dest); err != nil {
+		return err
+	}
+
+	count, err := b.count(source)
+	if err != nil {
+		return err
+	}
+	destCount, err := b.count(dest)
+	if err != nil {
+		return err
+	}
+	if destCount != count {
+		return fmt.Errorf("unable to migrate index [%s]: copied [%d] of its [%d] documents into [%s], keeping [%s]",
+			source, destCount, count, dest, source)
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) heldLeases() ([]string, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"expires_on": map[string]string{"gt": "now"},
+			},
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("unable to encode query as JSON: %w", err)
+	}
+
+	res, err := b.stateConn.Search(
+		b.stateConn.Search.WithIndex(leasesIndex),
+		b.stateConn.Search.WithBody(&body),
+		b.stateConn.Search.WithSize(100),
+		b.stateConn.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get scenario leases: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("unable to get scenario leases: %w", esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					ScenarioID string `json:"scenario_id"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	var held []string
+	for _, hit := range r.Hits.Hits {
+		held = append(held, hit.Source.ScenarioID)
+	}
+
+	return held, nil
+}
+
+func (b *Bootstrapper) blockWrites(index string, blocked bool) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]interface{}{
+		"index": map[string]interface{}{
+			"blocks": map[string]bool{"write": blocked},
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to encode index settings as JSON: %w", err)
+	}
+
+	res, err := b.stateConn.Indices.PutSettings(&body, b.stateConn.Indices.PutSettings.WithIndex(index))
+	if err != nil {
+		return fmt.Errorf("unable to set write block of index [%s]: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to set write block of index [%s]: %w", index, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+// nextIndex returns e.g. gds-scenarios-000002 for gds-scenarios-000001.
+func nextIndex(name, index string) string {
+	version, err := strconv.Atoi(strings.TrimPrefix(index, name+"-"))
+	if err != nil {
+		version = 0
+	}
+
+	return fmt.Sprintf("%s-%06d", name, version+1)
+}
+
+func (b *Bootstrapper) replaceIndex(name, oldIndex, newIndex string) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]interface{}{
+		"actions": []map[string]interface{}{
+			{"add": map[string]string{"index": newIndex, "alias": name}},
+			{"remove_index": map[string]string{"index": oldIndex}},
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to encode alias actions as JSON: %w", err)
+	}
+
+	res, err := b.stateConn.Indices.UpdateAliases(&body)
+	if err != nil {
+		return fmt.Errorf("unable to replace index [%s] with [%s]: %w", oldIndex, newIndex, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to replace index [%s] with [%s]: %w", oldIndex, newIndex, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) count(index string) (int, error) {
+	res, err := b.stateConn.Count(b.stateConn.Count.WithIndex(index))
+	if err != nil {
+		return 0, fmt.Errorf("unable to count documents in index [%s]: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("unable to count documents in index [%s]: %w", index, esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	return r.Count, nil
+}
+
+func (b *Bootstrapper) rollover(dataStream string) error {
+	res, err := b.stateConn.Indices.Rollover(dataStream)
+	if err != nil {
+		return fmt.Errorf("unable to roll over data stream [%s]: %w", dataStream, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to roll over data stream [%s]: %w", dataStream, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) reindex(source, dest string) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]interface{}{
+		"source": map[string]string{"index": source},
+		"dest":   map[string]string{"index": dest},
+	}); err != nil {
+		return fmt.Errorf("unable to encode reindex request as JSON: %w", err)
+	}
+
+	res, err := b.stateConn.Reindex(
+		&body,
+		b.stateConn.Reindex.WithWaitForCompletion(true),
+		b.stateConn.Reindex.WithRefresh(true),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to reindex [%s] into [%s]: %w", source, dest, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to reindex [%s] into [%s]: %w", source, dest, esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Failures []struct {
+			ID    string `json:"id"`
+			Cause struct {
+				Reason string `json:"reason"`
+			} `json:"cause"`
+		} `json:"failures"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	if len(r.Failures) > 0 {
+		var causes []string
+		for _, f := range r.Failures {
+			causes = append(causes, fmt.Sprintf("[%s] %s", f.ID, f.Cause.Reason))
+		}
+		return fmt.Errorf("unable to reindex [%s] into [%s]: %s", source, dest, strings.Join(causes, "; "))
+	}
+
+	return nil
+}
+
+func (b *Bootstrapper) deleteIndex(index string) error {
+	res, err := b.stateConn.Indices.Delete([]string{index})
+	if err != nil {
+		return fmt.Errorf("unable to delete index [%s]: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unable to delete index [%s]: %w", index, esutil.ResponseError(res))
+	}
+
+	return nil
+}
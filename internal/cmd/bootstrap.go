@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/config/gcm"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/bootstrap"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+)
+
+const flagMigrate = "migrate"
+
+func init() {
+	bootstrapCmd.Flags().StringP(flagConfigFile, "c", "config/qa.yml", "path to config file")
+	bootstrapCmd.Flags().Bool(flagMigrate, false, "migrate indices whose mappings are incompatible; the server must be stopped")
+}
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Install the index templates, ILM policies and watches of the state cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgFilePath, err := cmd.Flags().GetString(flagConfigFile)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadFromFile(cfgFilePath)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Validate(config.CommandBootstrap); err != nil {
+			return err
+		}
+
+		migrate, err := cmd.Flags().GetBool(flagMigrate)
+		if err != nil {
+			return err
+		}
+
+		b, err := newBootstrapper(cfg)
+		if err != nil {
+			return err
+		}
+
+		logging.Logger.Info("Bootstrapping state cluster...")
+		return b.Apply(migrate)
+	},
+}
+
+func newBootstrapper(cfg *config.Config) (*bootstrap.Bootstrapper, error) {
+	stateConn, err := dao.NewStateClusterConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := fs.Sub(gcm.StateCluster, "state_cluster")
+	if err != nil {
+		return nil, fmt.Errorf("unable to load state cluster assets: %w", err)
+	}
+
+	return bootstrap.New(stateConn, assets, cfg)
+}
+
+// checkStateCluster bootstraps the state cluster if requested, and refuses to
+// use it if the mappings of its indices are incompatible with the service's.
+func checkStateCluster(cfg *config.Config, apply bool) error {
+	b, err := newBootstrapper(cfg)
+	if err != nil {
+		return err
+	}
+
+	if apply {
+		logging.Logger.Info("Bootstrapping state cluster...")
+		return b.Apply(false)
+	}
+
+	drifts, err := b.CheckMappings()
+	if err != nil {
+		return fmt.Errorf("state cluster needs to be migrated: %w", err)
+	}
+
+	for _, drift := range drifts {
+		logging.Logger.Warn("index mapping is missing fields, run `ecbgd bootstrap` to add them",
+			zap.String("index", drift.Index),
+			zap.Strings("fields", drift.Missing),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/preflight"
+)
+
+func init() {
+	doctorCmd.Flags().StringP(flagConfigFile, "c", "config/qa.yml", "path to config file")
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the usage cluster, state cluster and Elastic Cloud API are usable",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgFilePath, err := cmd.Flags().GetString(flagConfigFile)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadFromFile(cfgFilePath)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Validate(config.CommandDoctor); err != nil {
+			return err
+		}
+
+		store, err := dao.NewStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		return runPreflight(cmd, cfg, store)
+	},
+}
+
+// runPreflight checks the external dependencies, prints the report, and fails
+// if any check failed.
+func runPreflight(cmd *cobra.Command, cfg *config.Config, store *dao.Store) error {
+	report := preflight.Run(preflight.Checks(cfg, store))
+	report.Print(cmd.OutOrStdout())
+
+	if err := report.Err(); err != nil {
+		// The report already describes what is wrong
+		cmd.SilenceUsage = true
+		return err
+	}
+
+	return nil
+}
@@ -25,6 +25,9 @@ func init() {
 	cobra.OnInitialize(initLogging)
 	rootCmd.PersistentFlags().StringP(flagLogLevel, "l", "info", "log level")
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(bootstrapCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(doctorCmd)
 }
 
 func Execute() error {
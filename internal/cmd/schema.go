@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/schema"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/watches"
+)
+
+const (
+	flagTemplatesDir = "templates-dir"
+	flagWatchesDir   = "watches-dir"
+	flagCheck        = "check"
+)
+
+func init() {
+	schemaCmd.Flags().String(flagTemplatesDir, "config/gcm/state_cluster/index_templates", "path to the state cluster's index templates")
+	schemaCmd.Flags().String(flagWatchesDir, "config/gcm/state_cluster/watches", "path to the state cluster's watches")
+	schemaCmd.Flags().Bool(flagCheck, false, "only check that the index templates and watches are up to date")
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Generate the mappings of the state cluster's index templates, and its watches, from the models",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cmd.Flags().GetString(flagTemplatesDir)
+		if err != nil {
+			return err
+		}
+
+		watchesDir, err := cmd.Flags().GetString(flagWatchesDir)
+		if err != nil {
+			return err
+		}
+
+		check, err := cmd.Flags().GetBool(flagCheck)
+		if err != nil {
+			return err
+		}
+
+		indices := make([]string, 0, len(schema.Indices))
+		for index := range schema.Indices {
+			indices = append(indices, index)
+		}
+		sort.Strings(indices)
+
+		var outdated []string
+		for _, index := range indices {
+			path := filepath.Join(dir, index+".json")
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("unable to read index template [%s]: %w", path, err)
+			}
+
+			rendered, err := schema.RenderIndexTemplate(index, body)
+			if err != nil {
+				return err
+			}
+
+			if bytes.Equal(body, rendered) {
+				continue
+			}
+
+			if check {
+				outdated = append(outdated, path)
+				continue
+			}
+
+			if err := ioutil.WriteFile(path, rendered, 0o644); err != nil {
+				return fmt.Errorf("unable to write index template [%s]: %w", path, err)
+			}
+			logging.Logger.Info("updated index template", zap.String("path", path))
+		}
+
+		// The watch in the repository has the default configuration; the
+		// configured one is rendered when bootstrapping.
+		path := filepath.Join(watchesDir, watches.ValidationFailures+".json")
+		body, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to read watch [%s]: %w", path, err)
+		}
+
+		rendered, err := watches.RenderValidationFailures(config.ValidationFailuresWatch{}, "")
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(body, rendered) {
+			if check {
+				outdated = append(outdated, path)
+			} else {
+				if err := ioutil.WriteFile(path, rendered, 0o644); err != nil {
+					return fmt.Errorf("unable to write watch [%s]: %w", path, err)
+				}
+				logging.Logger.Info("updated watch", zap.String("path", path))
+			}
+		}
+
+		if len(outdated) > 0 {
+			return fmt.Errorf("index templates or watches [%s] are outdated, run `go generate ./config/gcm`", strings.Join(outdated, ", "))
+		}
+
+		return nil
+	},
+}
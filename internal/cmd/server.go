@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"go.uber.org/zap"
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
 
@@ -12,18 +17,43 @@ import (
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
 
-	es "github.com/elastic/go-elasticsearch/v7"
-
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/auth"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/server"
 )
 
-const flagConfigFile = "config-file"
+const (
+	flagConfigFile    = "config-file"
+	flagBootstrap     = "bootstrap"
+	flagSkipPreflight = "skip-preflight"
+
+	flagAddress         = "address"
+	flagTLSCertFile     = "tls-cert-file"
+	flagTLSKeyFile      = "tls-key-file"
+	flagTLSClientCAFile = "tls-client-ca-file"
+	flagReadTimeout     = "read-timeout-seconds"
+	flagWriteTimeout    = "write-timeout-seconds"
+	flagIdleTimeout     = "idle-timeout-seconds"
+	flagMaxBodyBytes    = "max-body-bytes"
+)
 
 func init() {
 	serverCmd.Flags().StringP(flagConfigFile, "c", "config/qa.yml", "path to config file")
+	serverCmd.Flags().Bool(flagBootstrap, false, "install the state cluster's assets before starting")
+	serverCmd.Flags().Bool(flagSkipPreflight, false, "start without checking the usage cluster, state cluster and Elastic Cloud API")
+
+	// Server flags override the server section of the config file
+	serverCmd.Flags().String(flagAddress, "", "address to listen on (default \"localhost:8111\")")
+	serverCmd.Flags().String(flagTLSCertFile, "", "path to TLS certificate; enables TLS along with --tls-key-file")
+	serverCmd.Flags().String(flagTLSKeyFile, "", "path to TLS key")
+	serverCmd.Flags().String(flagTLSClientCAFile, "", "path to CA certificate that client certificates must be signed by")
+	serverCmd.Flags().Int(flagReadTimeout, 0, "timeout for reading requests, in seconds (default 30)")
+	serverCmd.Flags().Int(flagWriteTimeout, 0, "timeout for handling requests and writing responses, in seconds (default 60)")
+	serverCmd.Flags().Int(flagIdleTimeout, 0, "timeout for idle connections, in seconds (default 120)")
+	serverCmd.Flags().Int64(flagMaxBodyBytes, 0, "maximum size of request bodies, in bytes (default 10485760)")
 }
 
 var serverCmd = &cobra.Command{
@@ -40,63 +70,155 @@ var serverCmd = &cobra.Command{
 			return err
 		}
 
-		// Get scenario runner
-		scenarioRunner, err := runners.NewScenarioRunner(cfg)
+		if err := applyServerFlags(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := cfg.Validate(config.CommandServer); err != nil {
+			return err
+		}
+
+		store, err := dao.NewStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		skipPreflight, err := cmd.Flags().GetBool(flagSkipPreflight)
+		if err != nil {
+			return err
+		}
+		if !skipPreflight {
+			if err := runPreflight(cmd, cfg, store); err != nil {
+				return err
+			}
+		}
+
+		if cfg.GetStoreType() == config.StoreTypeElasticsearch {
+			apply, err := cmd.Flags().GetBool(flagBootstrap)
+			if err != nil {
+				return err
+			}
+
+			if err := checkStateCluster(cfg, apply); err != nil {
+				return err
+			}
+		}
+
+		authenticators, err := auth.NewAuthenticators(cfg)
 		if err != nil {
 			return err
 		}
+		if len(authenticators) == 0 {
+			logging.Logger.Warn("no authentication method is configured, the API is open to all")
+		}
 
-		stateConn, err := es.NewClient(es.Config{
-			Addresses: []string{cfg.StateCluster.Url},
-			Username:  cfg.StateCluster.Username,
-			Password:  cfg.StateCluster.Password,
-		})
+		// Get scenario runner
+		scenarioRunner, err := runners.NewScenarioRunner(cfg, store)
 		if err != nil {
-			return fmt.Errorf("unable to create connection to state cluster: %w", err)
+			return err
 		}
 
-		setupCloseHandler(scenarioRunner, stateConn)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
 		logging.Logger.Info("Starting existing scenarios...")
-		if err := startScenarios(scenarioRunner, stateConn); err != nil {
+		if err := scenarioRunner.Run(ctx); err != nil {
 			return err
 		}
 
 		logging.Logger.Info("Starting API server...")
-		if err := server.Start(scenarioRunner, stateConn); err != nil {
+		srv, err := server.New(cfg, scenarioRunner, store, authenticators)
+		if err != nil {
+			scenarioRunner.StopAll()
 			return err
 		}
 
-		return nil
+		logging.Logger.Info("API server listening",
+			zap.String("address", srv.Addr),
+			zap.Bool("tls", srv.TLSConfig != nil),
+		)
+		serverErr := make(chan error, 1)
+		go func() {
+			if srv.TLSConfig != nil {
+				// The certificate is already loaded in the TLS config
+				serverErr <- srv.ListenAndServeTLS("", "")
+				return
+			}
+			serverErr <- srv.ListenAndServe()
+		}()
+
+		select {
+		case err := <-serverErr:
+			scenarioRunner.StopAll()
+			return fmt.Errorf("API server failed: %w", err)
+		case <-ctx.Done():
+		}
+
+		return shutdown(srv, scenarioRunner, cfg.GetShutdownTimeout())
 	},
 }
 
-func startScenarios(scenarioRunner *runners.ScenarioRunner, stateConn *es.Client) error {
-	// Load all scenarios
-	scenarioDAO := dao.NewScenario(stateConn)
-	scenarios, err := scenarioDAO.ListAll()
-	if err != nil {
-		return fmt.Errorf("could not load all scenarios: %w", err)
+func shutdown(srv *http.Server, scenarioRunner *runners.ScenarioRunner, timeout time.Duration) error {
+	logging.Logger.Info("Shutting down...", zap.Duration("timeout", timeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	logging.Logger.Info("Stopping API server...")
+	if err := srv.Shutdown(ctx); err != nil {
+		logging.Logger.Error("unable to shut down API server gracefully", zap.Error(err))
 	}
 
-	// Ask scenario runner to run each scenario that's started
-	for _, scenario := range scenarios {
-		scenarioRunner.Start(&scenario)
+	logging.Logger.Info("Stopping Scenario Runner...")
+	if err := scenarioRunner.Shutdown(ctx); err != nil {
+		return fmt.Errorf("unable to stop scenario runner gracefully: %w", err)
 	}
 
 	return nil
 }
 
-func setupCloseHandler(scenarioRunner *runners.ScenarioRunner, stateConn *es.Client) {
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+func applyServerFlags(flags *pflag.FlagSet, cfg *config.Config) error {
+	stringFlags := map[string]*string{
+		flagAddress:         &cfg.Server.Address,
+		flagTLSCertFile:     &cfg.Server.TLS.CertFile,
+		flagTLSKeyFile:      &cfg.Server.TLS.KeyFile,
+		flagTLSClientCAFile: &cfg.Server.TLS.ClientCAFile,
+	}
+	for name, value := range stringFlags {
+		if !flags.Changed(name) {
+			continue
+		}
+
+		v, err := flags.GetString(name)
+		if err != nil {
+			return err
+		}
+		*value = v
+	}
+
+	intFlags := map[string]*int{
+		flagReadTimeout:  &cfg.Server.ReadTimeoutSeconds,
+		flagWriteTimeout: &cfg.Server.WriteTimeoutSeconds,
+		flagIdleTimeout:  &cfg.Server.IdleTimeoutSeconds,
+	}
+	for name, value := range intFlags {
+		if !flags.Changed(name) {
+			continue
+		}
 
-	go func() {
-		<-c
-		logging.Logger.Info("Stopping Scenario Runner... ")
+		v, err := flags.GetInt(name)
+		if err != nil {
+			return err
+		}
+		*value = v
+	}
 
-		scenarioRunner.StopAll()
+	if flags.Changed(flagMaxBodyBytes) {
+		v, err := flags.GetInt64(flagMaxBodyBytes)
+		if err != nil {
+			return err
+		}
+		cfg.Server.MaxBodyBytes = v
+	}
 
-		os.Exit(0)
-	}()
+	return nil
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,6 +15,46 @@ type ElasticsearchCluster struct {
 	Password string `yaml:"password"`
 }
 
+type AuthToken struct {
+	Name      string `yaml:"name"`
+	Role      string `yaml:"role"`
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+}
+
+type Notifier struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url"`
+	URLFile string            `yaml:"url_file"`
+	Headers map[string]string `yaml:"headers"`
+
+	SMTP struct {
+		Host         string   `yaml:"host"`
+		Port         int      `yaml:"port"`
+		Username     string   `yaml:"username"`
+		Password     string   `yaml:"password"`
+		PasswordFile string   `yaml:"password_file"`
+		From         string   `yaml:"from"`
+		To           []string `yaml:"to"`
+	} `yaml:"smtp"`
+}
+
+type WatchAction struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	Index   string            `yaml:"index"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	To      []string          `yaml:"to"`
+}
+
+type ValidationFailuresWatch struct {
+	PerScenario bool          `yaml:"per_scenario"`
+	Interval    string        `yaml:"interval"`
+	Actions     []WatchAction `yaml:"actions"`
+}
+
 type Config struct {
 	API struct {
 		Url string `yaml:"url"`
@@ -21,8 +63,107 @@ type Config struct {
 
 	UsageCluster ElasticsearchCluster `yaml:"usage_cluster"`
 	StateCluster ElasticsearchCluster `yaml:"state_cluster"`
+
+	Store struct {
+		Type string `yaml:"type"`
+		Path string `yaml:"path"`
+	} `yaml:"store"`
+
+	Encryption struct {
+		KMS     string `yaml:"kms"`
+		Key     string `yaml:"key"` // base64-encoded 256-bit key
+		KeyFile string `yaml:"key_file"`
+	} `yaml:"encryption"`
+
+	Server struct {
+		Address string `yaml:"address"`
+
+		TLS struct {
+			CertFile     string `yaml:"cert_file"`
+			KeyFile      string `yaml:"key_file"`
+			ClientCAFile string `yaml:"client_ca_file"`
+		} `yaml:"tls"`
+
+		ReadTimeoutSeconds     int   `yaml:"read_timeout_seconds"`
+		WriteTimeoutSeconds    int   `yaml:"write_timeout_seconds"`
+		IdleTimeoutSeconds     int   `yaml:"idle_timeout_seconds"`
+		MaxBodyBytes           int64 `yaml:"max_body_bytes"`
+		ShutdownTimeoutSeconds int   `yaml:"shutdown_timeout_seconds"`
+	} `yaml:"server"`
+
+	Auth struct {
+		Tokens               []AuthToken `yaml:"tokens"`
+		ElasticsearchAPIKeys bool        `yaml:"elasticsearch_api_keys"`
+	} `yaml:"auth"`
+
+	Alerting struct {
+		Notifiers             []Notifier `yaml:"notifiers"`
+		DefaultNotifiers      []string   `yaml:"default_notifiers"`
+		RepeatIntervalSeconds int        `yaml:"repeat_interval_seconds"`
+	} `yaml:"alerting"`
+
+	Watches struct {
+		ValidationFailures ValidationFailuresWatch `yaml:"validation_failures"`
+	} `yaml:"watches"`
+
+	Leases struct {
+		Enabled         bool `yaml:"enabled"`
+		DurationSeconds int  `yaml:"duration_seconds"`
+	} `yaml:"leases"`
+
+	Setup struct {
+		AssetsDir string `yaml:"assets_dir"`
+	} `yaml:"setup"`
+
+	Deployments struct {
+		HealthTimeoutSeconds      int `yaml:"health_timeout_seconds"`
+		HealthPollIntervalSeconds int `yaml:"health_poll_interval_seconds"`
+	} `yaml:"deployments"`
 }
 
+const (
+	StoreTypeElasticsearch = "elasticsearch"
+	StoreTypeLocal         = "local"
+)
+
+const (
+	KMSTypeLocal = "local"
+)
+
+const (
+	NotifierTypeWebhook = "webhook"
+	NotifierTypeSlack   = "slack"
+	NotifierTypeEmail   = "email"
+)
+
+const (
+	WatchActionTypeIndex   = "index"
+	WatchActionTypeWebhook = "webhook"
+	WatchActionTypeEmail   = "email"
+)
+
+const (
+	defaultStorePath = "data"
+
+	defaultServerAddress      = "localhost:8111"
+	defaultServerReadTimeout  = 30 * time.Second
+	defaultServerWriteTimeout = 60 * time.Second
+	defaultServerIdleTimeout  = 2 * time.Minute
+	defaultServerMaxBodyBytes = 10 << 20
+	defaultShutdownTimeout    = 30 * time.Second
+
+	defaultLeaseDuration = 30 * time.Second
+
+	defaultAlertRepeatInterval = 24 * time.Hour
+
+	defaultWatchInterval = "1d"
+
+	defaultDeploymentHealthTimeout      = 30 * time.Minute
+	defaultDeploymentHealthPollInterval = 10 * time.Second
+)
+
+// LoadFromFile loads the configuration from the given YAML file, overridden by
+// environment variables. It must then be validated with Validate.
 func LoadFromFile(path string) (*Config, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -34,5 +175,121 @@ func LoadFromFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("unable to parse config file [%s]: %w", path, err)
 	}
 
+	if err := applyEnv(&c, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
+
+func (c *Config) GetDeploymentHealthTimeout() time.Duration {
+	if c.Deployments.HealthTimeoutSeconds <= 0 {
+		return defaultDeploymentHealthTimeout
+	}
+
+	return time.Duration(c.Deployments.HealthTimeoutSeconds) * time.Second
+}
+
+func (c *Config) GetDeploymentHealthPollInterval() time.Duration {
+	if c.Deployments.HealthPollIntervalSeconds <= 0 {
+		return defaultDeploymentHealthPollInterval
+	}
+
+	return time.Duration(c.Deployments.HealthPollIntervalSeconds) * time.Second
+}
+
+func (c *Config) GetServerAddress() string {
+	if c.Server.Address == "" {
+		return defaultServerAddress
+	}
+
+	return c.Server.Address
+}
+
+func (c *Config) GetServerReadTimeout() time.Duration {
+	if c.Server.ReadTimeoutSeconds <= 0 {
+		return defaultServerReadTimeout
+	}
+
+	return time.Duration(c.Server.ReadTimeoutSeconds) * time.Second
+}
+
+func (c *Config) GetServerWriteTimeout() time.Duration {
+	if c.Server.WriteTimeoutSeconds <= 0 {
+		return defaultServerWriteTimeout
+	}
+
+	return time.Duration(c.Server.WriteTimeoutSeconds) * time.Second
+}
+
+func (c *Config) GetServerIdleTimeout() time.Duration {
+	if c.Server.IdleTimeoutSeconds <= 0 {
+		return defaultServerIdleTimeout
+	}
+
+	return time.Duration(c.Server.IdleTimeoutSeconds) * time.Second
+}
+
+func (c *Config) GetServerMaxBodyBytes() int64 {
+	if c.Server.MaxBodyBytes <= 0 {
+		return defaultServerMaxBodyBytes
+	}
+
+	return c.Server.MaxBodyBytes
+}
+
+func (c *Config) GetShutdownTimeout() time.Duration {
+	if c.Server.ShutdownTimeoutSeconds <= 0 {
+		return defaultShutdownTimeout
+	}
+
+	return time.Duration(c.Server.ShutdownTimeoutSeconds) * time.Second
+}
+
+func (c *Config) GetLeaseDuration() time.Duration {
+	if c.Leases.DurationSeconds <= 0 {
+		return defaultLeaseDuration
+	}
+
+	return time.Duration(c.Leases.DurationSeconds) * time.Second
+}
+
+func (c *Config) GetStoreType() string {
+	if c.Store.Type == "" {
+		return StoreTypeElasticsearch
+	}
+
+	return c.Store.Type
+}
+
+func (c *Config) GetStorePath() string {
+	if c.Store.Path == "" {
+		return defaultStorePath
+	}
+
+	return c.Store.Path
+}
+
+func (c *Config) GetEncryptionKMS() string {
+	if c.Encryption.KMS == "" {
+		return KMSTypeLocal
+	}
+
+	return c.Encryption.KMS
+}
+
+func (c *Config) GetAlertRepeatInterval() time.Duration {
+	if c.Alerting.RepeatIntervalSeconds <= 0 {
+		return defaultAlertRepeatInterval
+	}
+
+	return time.Duration(c.Alerting.RepeatIntervalSeconds) * time.Second
+}
+
+func (w *ValidationFailuresWatch) GetInterval() string {
+	if w.Interval == "" {
+		return defaultWatchInterval
+	}
+
+	return w.Interval
+}
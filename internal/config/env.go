@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	envPrefix = "ECBGD"
+
+	envFileSuffix = "_FILE"
+
+	envLegacyAPIKey = "EC_API_KEY"
+)
+
+// applyEnv overrides settings with ECBGD_-prefixed environment variables, e.g.
+// ECBGD_USAGE_CLUSTER_PASSWORD, or with the contents of the file named by the
+// _FILE variant of the variable.
+func applyEnv(c *Config, lookupEnv func(string) (string, bool)) error {
+	if apiKey, ok := lookupEnv(envLegacyAPIKey); ok {
+		c.API.Key = apiKey
+	}
+
+	return applyEnvToStruct(reflect.ValueOf(c).Elem(), envPrefix, lookupEnv)
+}
+
+func applyEnvToStruct(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	t := v.Type()
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		names[yamlName(t.Field(i))] = true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" || name == "-" {
+			continue
+		}
+
+		envName := prefix + "_" + strings.ToUpper(name)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnvToStruct(field, envName, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookupEnv(envName)
+		indirect := !strings.HasSuffix(name, "_file") && !names[name+"_file"]
+		if path, fileOK := lookupEnv(envName + envFileSuffix); fileOK && indirect {
+			secret, err := ReadSecret("", path)
+			if err != nil {
+				return fmt.Errorf("invalid value of [%s]: %w", envName+envFileSuffix, err)
+			}
+			value, ok = secret, true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value of [%s]: %w", envName, err)
+		}
+	}
+
+	return nil
+}
+
+func yamlName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	default:
+		return fmt.Errorf("settings of type [%s] cannot be set from the environment", field.Type())
+	}
+
+	return nil
+}
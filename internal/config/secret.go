@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ReadSecret returns the given value, or the contents of the file at the given
+// path if one is set, such as a mounted secret. Surrounding whitespace, like the
+// file's trailing newline, is removed.
+func ReadSecret(value, path string) (string, error) {
+	if path == "" {
+		return value, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file [%s]: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
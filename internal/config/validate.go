@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Command string
+
+const (
+	CommandServer    Command = "server"
+	CommandBootstrap Command = "bootstrap"
+	CommandDoctor    Command = "doctor"
+)
+
+// The doctor command requires no connection settings, so that it can report
+// which connections are not usable.
+func (c *Config) Validate(cmd Command) error {
+	var problems []string
+	require := func(key, value string) {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("missing [%s] (%s)", key, envName(key)))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		problems = append(problems, fmt.Sprintf("invalid [%s] (%s): [%s] is not one of [%s]",
+			key, envName(key), value, strings.Join(allowed, ", ")))
+	}
+
+	switch cmd {
+	case CommandServer:
+		require("api.url", c.API.Url)
+		require("api.key", c.API.Key)
+		require("usage_cluster.url", c.UsageCluster.Url)
+		if c.GetStoreType() == StoreTypeElasticsearch || c.Auth.ElasticsearchAPIKeys {
+			require("state_cluster.url", c.StateCluster.Url)
+		}
+	case CommandBootstrap:
+		require("state_cluster.url", c.StateCluster.Url)
+	}
+
+	oneOf("store.type", c.GetStoreType(), StoreTypeElasticsearch, StoreTypeLocal)
+
+	oneOf("encryption.kms", c.GetEncryptionKMS(), KMSTypeLocal)
+
+	notifiers := map[string]bool{}
+	for i, n := range c.Alerting.Notifiers {
+		key := fmt.Sprintf("alerting.notifiers[%d]", i)
+		switch {
+		case n.Name == "":
+			problems = append(problems, fmt.Sprintf("missing [%s.name]", key))
+		case notifiers[n.Name]:
+			problems = append(problems, fmt.Sprintf("invalid [%s.name]: notifier [%s] is defined more than once", key, n.Name))
+		}
+		notifiers[n.Name] = true
+
+		switch n.Type {
+		case NotifierTypeWebhook, NotifierTypeSlack:
+			if n.URL == "" && n.URLFile == "" {
+				problems = append(problems, fmt.Sprintf("missing [%s.url]", key))
+			}
+		case NotifierTypeEmail:
+			if n.SMTP.Host == "" {
+				problems = append(problems, fmt.Sprintf("missing [%s.smtp.host]", key))
+			}
+			if n.SMTP.From == "" {
+				problems = append(problems, fmt.Sprintf("missing [%s.smtp.from]", key))
+			}
+			if len(n.SMTP.To) == 0 {
+				problems = append(problems, fmt.Sprintf("missing [%s.smtp.to]", key))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("invalid [%s.type]: [%s] is not one of [%s]",
+				key, n.Type, strings.Join([]string{NotifierTypeWebhook, NotifierTypeSlack, NotifierTypeEmail}, ", ")))
+		}
+	}
+	for _, name := range c.Alerting.DefaultNotifiers {
+		if !notifiers[name] {
+			problems = append(problems, fmt.Sprintf("invalid [alerting.default_notifiers]: notifier [%s] is not defined", name))
+		}
+	}
+
+	watch := c.Watches.ValidationFailures
+	if watch.PerScenario && c.GetStoreType() != StoreTypeElasticsearch {
+		problems = append(problems, fmt.Sprintf("invalid [watches.validation_failures.per_scenario] (%s): watches require the [%s] store",
+			envName("watches.validation_failures.per_scenario"), StoreTypeElasticsearch))
+	}
+	actions := map[string]bool{}
+	for i, a := range watch.Actions {
+		key := fmt.Sprintf("watches.validation_failures.actions[%d]", i)
+		switch {
+		case a.Name == "":
+			problems = append(problems, fmt.Sprintf("missing [%s.name]", key))
+		case actions[a.Name]:
+			problems = append(problems, fmt.Sprintf("invalid [%s.name]: action [%s] is defined more than once", key, a.Name))
+		}
+		actions[a.Name] = true
+
+		switch a.Type {
+		case WatchActionTypeIndex:
+			if a.Index == "" {
+				problems = append(problems, fmt.Sprintf("missing [%s.index]", key))
+			}
+		case WatchActionTypeWebhook:
+			if a.URL == "" {
+				problems = append(problems, fmt.Sprintf("missing [%s.url]", key))
+			}
+		case WatchActionTypeEmail:
+			if len(a.To) == 0 {
+				problems = append(problems, fmt.Sprintf("missing [%s.to]", key))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("invalid [%s.type]: [%s] is not one of [%s]",
+				key, a.Type, strings.Join([]string{WatchActionTypeIndex, WatchActionTypeWebhook, WatchActionTypeEmail}, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
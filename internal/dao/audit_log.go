@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const (
+	auditLogIndex = "gds-audit-log"
+)
+
+type AuditLog struct {
+	stateConn *es.Client
+}
+
+func NewAuditLog(stateConn *es.Client) *AuditLog {
+	al := new(AuditLog)
+	al.stateConn = stateConn
+
+	return al
+}
+
+func (al *AuditLog) Save(event *models.AuditEvent) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(event); err != nil {
+		return fmt.Errorf("unable to encode audit event as JSON: %w", err)
+	}
+
+	res, err := al.stateConn.Index(
+		auditLogIndex,
+		&buf,
+		al.stateConn.Index.WithOpType("create"),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to persist audit event: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
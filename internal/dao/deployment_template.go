@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
 )
 
@@ -38,6 +40,7 @@ func (dt *DeploymentConfiguration) ListAll() ([]models.DeploymentConfiguration,
 		dt.stateConn.Search.WithContext(context.Background()),
 		dt.stateConn.Search.WithIndex(deploymentConfigsIndex),
 		dt.stateConn.Search.WithSize(10000),
+		dt.stateConn.Search.WithSeqNoPrimaryTerm(true),
 	)
 
 	if err != nil {
@@ -46,14 +49,16 @@ func (dt *DeploymentConfiguration) ListAll() ([]models.DeploymentConfiguration,
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	var r struct {
 		Hits struct {
 			Hits []struct {
-				ID     string                         `json:"_id"`
-				Source models.DeploymentConfiguration `json:"_source"`
+				ID          string                         `json:"_id"`
+				SeqNo       int                            `json:"_seq_no"`
+				PrimaryTerm int                            `json:"_primary_term"`
+				Source      models.DeploymentConfiguration `json:"_source"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
@@ -63,6 +68,7 @@ func (dt *DeploymentConfiguration) ListAll() ([]models.DeploymentConfiguration,
 	}
 
 	for _, hit := range r.Hits.Hits {
+		hit.Source.Version = models.Version{SeqNo: hit.SeqNo, PrimaryTerm: hit.PrimaryTerm}
 		deploymentConfigs = append(deploymentConfigs, hit.Source)
 	}
 	return deploymentConfigs, nil
@@ -72,7 +78,7 @@ func (dt *DeploymentConfiguration) Get(id string) (*models.DeploymentConfigurati
 	if exists, err := dt.indexExists(); err != nil {
 		return nil, err
 	} else if !exists {
-		return nil, nil
+		return nil, fmt.Errorf("deployment configuration [%s] %w", id, ErrNotFound)
 	}
 
 	res, err := dt.stateConn.Get(deploymentConfigsIndex, id)
@@ -81,13 +87,19 @@ func (dt *DeploymentConfiguration) Get(id string) (*models.DeploymentConfigurati
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("deployment configuration [%s] %w", id, ErrNotFound)
+	}
+
 	if res.IsError() {
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	var r struct {
-		ID     string                         `json:"_id"`
-		Source models.DeploymentConfiguration `json:"_source"`
+		ID          string                         `json:"_id"`
+		SeqNo       int                            `json:"_seq_no"`
+		PrimaryTerm int                            `json:"_primary_term"`
+		Source      models.DeploymentConfiguration `json:"_source"`
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
@@ -95,29 +107,26 @@ func (dt *DeploymentConfiguration) Get(id string) (*models.DeploymentConfigurati
 	}
 
 	deploymentConfig := r.Source
+	deploymentConfig.Version = models.Version{SeqNo: r.SeqNo, PrimaryTerm: r.PrimaryTerm}
 	return &deploymentConfig, nil
 }
 
+// Save persists the deployment configuration, unless it was changed in the state
+// cluster since it was read, in which case a *ConflictError is returned.
+// Configurations that were not read from the state cluster are created. The
+// configuration's version is updated on success.
 func (dt *DeploymentConfiguration) Save(deploymentConfig *models.DeploymentConfiguration) error {
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(deploymentConfig); err != nil {
 		return fmt.Errorf("unable to encode deployment configuration [%s] as JSON: %w", deploymentConfig.ID, err)
 	}
 
-	res, err := dt.stateConn.Index(
-		deploymentConfigsIndex,
-		&buf,
-		dt.stateConn.Index.WithDocumentID(deploymentConfig.ID),
-	)
+	version, err := indexVersioned(dt.stateConn, deploymentConfigsIndex, deploymentConfig.ID, &buf, deploymentConfig.Version)
 	if err != nil {
 		return fmt.Errorf("unable to persist deployment configuration [%s]: %w", deploymentConfig.ID, err)
 	}
-	defer res.Body.Close()
-
-	if res.IsError() {
-		return handleESAPIErrorResponse(res)
-	}
 
+	deploymentConfig.Version = version
 	return nil
 }
 
@@ -129,10 +138,10 @@ func (dt *DeploymentConfiguration) indexExists() (bool, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		if res.StatusCode == 404 {
+		if res.StatusCode == http.StatusNotFound {
 			return false, nil
 		}
-		return false, handleESAPIErrorResponse(res)
+		return false, esutil.ResponseError(res)
 	}
 
 	return true, nil
@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/deployment"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/secrets"
+)
+
+// Scenarios saved with plaintext credentials are encrypted the next time they
+// are saved.
+type encryptedScenarios struct {
+	ScenarioRepository
+	kms secrets.KMS
+}
+
+func NewEncryptedScenarios(scenarios ScenarioRepository, kms secrets.KMS) ScenarioRepository {
+	return &encryptedScenarios{
+		ScenarioRepository: scenarios,
+		kms:                kms,
+	}
+}
+
+func (r *encryptedScenarios) ListAll() ([]models.Scenario, error) {
+	scenarios, err := r.ScenarioRepository.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range scenarios {
+		if err := r.decrypt(&scenarios[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return scenarios, nil
+}
+
+func (r *encryptedScenarios) Get(id string) (*models.Scenario, error) {
+	scenario, err := r.ScenarioRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.decrypt(scenario); err != nil {
+		return nil, err
+	}
+
+	return scenario, nil
+}
+
+func (r *encryptedScenarios) Save(scenario *models.Scenario) error {
+	encrypted := *scenario
+	encrypted.EncryptedCredentials = nil
+	if !scenario.DeploymentCredentials.IsZero() {
+		plaintext, err := json.Marshal(scenario.DeploymentCredentials)
+		if err != nil {
+			return fmt.Errorf("unable to encode credentials of scenario [%s] as JSON: %w", scenario.ID, err)
+		}
+
+		envelope, err := secrets.Seal(r.kms, plaintext)
+		if err != nil {
+			return fmt.Errorf("unable to encrypt credentials of scenario [%s]: %w", scenario.ID, err)
+		}
+
+		encrypted.EncryptedCredentials = envelope
+		encrypted.DeploymentCredentials = deployment.Credentials{}
+	}
+
+	if err := r.ScenarioRepository.Save(&encrypted); err != nil {
+		return err
+	}
+
+	scenario.Version = encrypted.Version
+	return nil
+}
+
+func (r *encryptedScenarios) decrypt(scenario *models.Scenario) error {
+	if scenario.EncryptedCredentials == nil {
+		return nil
+	}
+
+	plaintext, err := secrets.Open(r.kms, scenario.EncryptedCredentials)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt credentials of scenario [%s]: %w", scenario.ID, err)
+	}
+
+	var creds deployment.Credentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return fmt.Errorf("unable to parse credentials of scenario [%s]: %w", scenario.ID, err)
+	}
+
+	scenario.DeploymentCredentials = creds
+	scenario.EncryptedCredentials = nil
+
+	return nil
+}
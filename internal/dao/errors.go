@@ -0,0 +1,26 @@
+package dao
+
+import (
+	"fmt"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+var (
+	ErrNotFound = esutil.ErrNotFound
+
+	ErrConflict = esutil.ErrConflict
+)
+
+type ConflictError struct {
+	Index string
+	ID    string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("document [%s] in index [%s] was changed concurrently", e.ID, e.Index)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,163 @@
+package dao
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const (
+	leasesIndex = "gds-scenario-leases"
+)
+
+type Lease struct {
+	stateConn *es.Client
+}
+
+func NewLease(stateConn *es.Client) *Lease {
+	l := new(Lease)
+	l.stateConn = stateConn
+
+	return l
+}
+
+type versionedLease struct {
+	lease       models.Lease
+	seqNo       int
+	primaryTerm int
+}
+
+// TryAcquire returns false if another owner holds an unexpired lease on the
+// scenario.
+func (l *Lease) TryAcquire(scenarioID, owner string, duration time.Duration) (bool, error) {
+	current, err := l.get(scenarioID)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	lease := models.Lease{
+		ScenarioID: scenarioID,
+		Owner:      owner,
+		AcquiredOn: now,
+		RenewedOn:  now,
+		ExpiresOn:  now.Add(duration),
+	}
+
+	if current == nil {
+		return l.write(lease, nil)
+	}
+
+	if current.lease.Owner != owner && !current.lease.IsExpired(now) {
+		return false, nil
+	}
+
+	if current.lease.Owner == owner {
+		lease.AcquiredOn = current.lease.AcquiredOn
+	}
+
+	return l.write(lease, current)
+}
+
+func (l *Lease) Release(scenarioID, owner string) error {
+	current, err := l.get(scenarioID)
+	if err != nil {
+		return err
+	}
+
+	if current == nil || current.lease.Owner != owner {
+		return nil
+	}
+
+	res, err := l.stateConn.Delete(
+		leasesIndex,
+		scenarioID,
+		l.stateConn.Delete.WithIfSeqNo(current.seqNo),
+		l.stateConn.Delete.WithIfPrimaryTerm(current.primaryTerm),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to release lease on scenario [%s]: %w", scenarioID, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusConflict && res.StatusCode != http.StatusNotFound {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
+
+func (l *Lease) get(scenarioID string) (*versionedLease, error) {
+	res, err := l.stateConn.Get(leasesIndex, scenarioID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get lease on scenario [%s]: %w", scenarioID, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if res.IsError() {
+		return nil, esutil.ResponseError(res)
+	}
+
+	var r struct {
+		SeqNo       int          `json:"_seq_no"`
+		PrimaryTerm int          `json:"_primary_term"`
+		Source      models.Lease `json:"_source"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	return &versionedLease{
+		lease:       r.Source,
+		seqNo:       r.SeqNo,
+		primaryTerm: r.PrimaryTerm,
+	}, nil
+}
+
+func (l *Lease) write(lease models.Lease, current *versionedLease) (bool, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(lease); err != nil {
+		return false, fmt.Errorf("unable to encode lease on scenario [%s] as JSON: %w", lease.ScenarioID, err)
+	}
+
+	opts := []func(*esapi.IndexRequest){
+		l.stateConn.Index.WithDocumentID(lease.ScenarioID),
+		l.stateConn.Index.WithRefresh("true"),
+	}
+	if current == nil {
+		opts = append(opts, l.stateConn.Index.WithOpType("create"))
+	} else {
+		opts = append(opts,
+			l.stateConn.Index.WithIfSeqNo(current.seqNo),
+			l.stateConn.Index.WithIfPrimaryTerm(current.primaryTerm),
+		)
+	}
+
+	res, err := l.stateConn.Index(leasesIndex, &buf, opts...)
+	if err != nil {
+		return false, fmt.Errorf("unable to persist lease on scenario [%s]: %w", lease.ScenarioID, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+
+	if res.IsError() {
+		return false, esutil.ResponseError(res)
+	}
+
+	return true, nil
+}
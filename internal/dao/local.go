@@ -0,0 +1,446 @@
+package dao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const localPrimaryTerm = 1
+
+// NewLocalStore returns a store that keeps the service's state in JSON files in
+// the given directory. Local stores may not be shared between processes.
+func NewLocalStore(dir string) (*Store, error) {
+	var mu sync.Mutex
+	collection := func(name string) (*localCollection, error) {
+		c := &localCollection{
+			name: name,
+			dir:  filepath.Join(dir, name),
+			mu:   &mu,
+		}
+		if err := os.MkdirAll(c.dir, 0o700); err != nil {
+			return nil, fmt.Errorf("unable to create local store directory [%s]: %w", c.dir, err)
+		}
+
+		return c, nil
+	}
+
+	scenarios, err := collection(scenariosIndex)
+	if err != nil {
+		return nil, err
+	}
+	deploymentConfigs, err := collection(deploymentConfigsIndex)
+	if err != nil {
+		return nil, err
+	}
+	validationResults, err := collection(validationResultsIndex)
+	if err != nil {
+		return nil, err
+	}
+	leases, err := collection(leasesIndex)
+	if err != nil {
+		return nil, err
+	}
+	auditLog, err := collection(auditLogIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Scenarios:                &localScenarios{scenarios},
+		DeploymentConfigurations: &localDeploymentConfigurations{deploymentConfigs},
+		ValidationResults:        &localValidationResults{validationResults},
+		Leases:                   &localLeases{leases},
+		AuditLog:                 &localAuditLog{auditLog},
+		ping: func() error {
+			if _, err := os.Stat(dir); err != nil {
+				return fmt.Errorf("unable to access local store directory [%s]: %w", dir, err)
+			}
+
+			return nil
+		},
+	}, nil
+}
+
+type localDocument struct {
+	SeqNo  int             `json:"_seq_no"`
+	Source json.RawMessage `json:"_source"`
+}
+
+func (d localDocument) version() models.Version {
+	return models.Version{SeqNo: d.SeqNo, PrimaryTerm: localPrimaryTerm}
+}
+
+type localCollection struct {
+	name string
+	dir  string
+	mu   *sync.Mutex
+}
+
+func (c *localCollection) path(id string) string {
+	return filepath.Join(c.dir, url.PathEscape(id)+".json")
+}
+
+func (c *localCollection) list() ([]localDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list documents in [%s]: %w", c.name, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	var docs []localDocument
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		id, err := url.PathUnescape(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+
+		doc, err := c.read(id)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, *doc)
+	}
+
+	return docs, nil
+}
+
+func (c *localCollection) get(id string) (*localDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.read(id)
+}
+
+// put creates the document if its version is zero, and otherwise only replaces
+// it if it is still at the given version.
+func (c *localCollection) put(id string, source interface{}, version models.Version) (models.Version, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write(id, source, version)
+}
+
+func (c *localCollection) delete(id string, version models.Version) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.read(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if current.version() != version {
+		return &ConflictError{Index: c.name, ID: id}
+	}
+
+	if err := os.Remove(c.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to delete document [%s] in [%s]: %w", id, c.name, err)
+	}
+
+	return nil
+}
+
+// The caller must hold c.mu.
+func (c *localCollection) read(id string) (*localDocument, error) {
+	data, err := ioutil.ReadFile(c.path(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("document [%s] in [%s] %w", id, c.name, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read document [%s] in [%s]: %w", id, c.name, err)
+	}
+
+	var doc localDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse document [%s] in [%s]: %w", id, c.name, err)
+	}
+
+	return &doc, nil
+}
+
+// The caller must hold c.mu.
+func (c *localCollection) write(id string, source interface{}, version models.Version) (models.Version, error) {
+	current, err := c.read(id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return models.Version{}, err
+	}
+
+	var doc localDocument
+	switch {
+	case current == nil && version.IsZero():
+		doc.SeqNo = 0
+	case current != nil && current.version() == version:
+		doc.SeqNo = current.SeqNo + 1
+	default:
+		return models.Version{}, &ConflictError{Index: c.name, ID: id}
+	}
+
+	doc.Source, err = json.Marshal(source)
+	if err != nil {
+		return models.Version{}, fmt.Errorf("unable to encode document [%s] in [%s] as JSON: %w", id, c.name, err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return models.Version{}, fmt.Errorf("unable to encode document [%s] in [%s] as JSON: %w", id, c.name, err)
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return models.Version{}, fmt.Errorf("unable to write document [%s] in [%s]: %w", id, c.name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return models.Version{}, fmt.Errorf("unable to write document [%s] in [%s]: %w", id, c.name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return models.Version{}, fmt.Errorf("unable to write document [%s] in [%s]: %w", id, c.name, err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(id)); err != nil {
+		return models.Version{}, fmt.Errorf("unable to write document [%s] in [%s]: %w", id, c.name, err)
+	}
+
+	return doc.version(), nil
+}
+
+type localScenarios struct {
+	c *localCollection
+}
+
+func (ls *localScenarios) ListAll() ([]models.Scenario, error) {
+	docs, err := ls.c.list()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list all scenarios: %w", err)
+	}
+
+	var scenarios []models.Scenario
+	for _, doc := range docs {
+		var scenario models.Scenario
+		if err := json.Unmarshal(doc.Source, &scenario); err != nil {
+			return nil, fmt.Errorf("unable to parse scenario: %w", err)
+		}
+		scenario.Version = doc.version()
+		scenarios = append(scenarios, scenario)
+	}
+
+	return scenarios, nil
+}
+
+func (ls *localScenarios) Get(id string) (*models.Scenario, error) {
+	doc, err := ls.c.get(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("scenario [%s] %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get scenario [%s]: %w", id, err)
+	}
+
+	var scenario models.Scenario
+	if err := json.Unmarshal(doc.Source, &scenario); err != nil {
+		return nil, fmt.Errorf("unable to parse scenario [%s]: %w", id, err)
+	}
+	scenario.Version = doc.version()
+
+	return &scenario, nil
+}
+
+func (ls *localScenarios) Save(scenario *models.Scenario) error {
+	version, err := ls.c.put(scenario.ID, scenario, scenario.Version)
+	if err != nil {
+		return fmt.Errorf("unable to persist scenario [%s]: %w", scenario.ID, err)
+	}
+
+	scenario.Version = version
+	return nil
+}
+
+type localDeploymentConfigurations struct {
+	c *localCollection
+}
+
+func (ld *localDeploymentConfigurations) ListAll() ([]models.DeploymentConfiguration, error) {
+	docs, err := ld.c.list()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list all deployment configurations: %w", err)
+	}
+
+	var deploymentConfigs []models.DeploymentConfiguration
+	for _, doc := range docs {
+		var deploymentConfig models.DeploymentConfiguration
+		if err := json.Unmarshal(doc.Source, &deploymentConfig); err != nil {
+			return nil, fmt.Errorf("unable to parse deployment configuration: %w", err)
+		}
+		deploymentConfig.Version = doc.version()
+		deploymentConfigs = append(deploymentConfigs, deploymentConfig)
+	}
+
+	return deploymentConfigs, nil
+}
+
+func (ld *localDeploymentConfigurations) Get(id string) (*models.DeploymentConfiguration, error) {
+	doc, err := ld.c.get(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("deployment configuration [%s] %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get deployment configuration [%s]: %w", id, err)
+	}
+
+	var deploymentConfig models.DeploymentConfiguration
+	if err := json.Unmarshal(doc.Source, &deploymentConfig); err != nil {
+		return nil, fmt.Errorf("unable to parse deployment configuration [%s]: %w", id, err)
+	}
+	deploymentConfig.Version = doc.version()
+
+	return &deploymentConfig, nil
+}
+
+func (ld *localDeploymentConfigurations) Save(deploymentConfig *models.DeploymentConfiguration) error {
+	version, err := ld.c.put(deploymentConfig.ID, deploymentConfig, deploymentConfig.Version)
+	if err != nil {
+		return fmt.Errorf("unable to persist deployment configuration [%s]: %w", deploymentConfig.ID, err)
+	}
+
+	deploymentConfig.Version = version
+	return nil
+}
+
+type localValidationResults struct {
+	c *localCollection
+}
+
+func (lv *localValidationResults) ListAllForScenario(scenarioID string) ([]models.ValidationResult, error) {
+	docs, err := lv.c.list()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list all validation results for scenario [%s]: %w", scenarioID, err)
+	}
+
+	var results []models.ValidationResult
+	for _, doc := range docs {
+		var result models.ValidationResult
+		if err := json.Unmarshal(doc.Source, &result); err != nil {
+			return nil, fmt.Errorf("unable to parse validation result for scenario [%s]: %w", scenarioID, err)
+		}
+		if result.ScenarioID == scenarioID {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+func (lv *localValidationResults) Save(result *models.ValidationResult) error {
+	if _, err := lv.c.put(uuid.New().String(), result, models.Version{}); err != nil {
+		return fmt.Errorf("unable to persist validation result for scenario [%s]: %w", result.ScenarioID, err)
+	}
+
+	return nil
+}
+
+type localAuditLog struct {
+	c *localCollection
+}
+
+func (la *localAuditLog) Save(event *models.AuditEvent) error {
+	if _, err := la.c.put(uuid.New().String(), event, models.Version{}); err != nil {
+		return fmt.Errorf("unable to persist audit event: %w", err)
+	}
+
+	return nil
+}
+
+type localLeases struct {
+	c *localCollection
+}
+
+func (ll *localLeases) TryAcquire(scenarioID, owner string, duration time.Duration) (bool, error) {
+	ll.c.mu.Lock()
+	defer ll.c.mu.Unlock()
+
+	now := time.Now()
+	lease := models.Lease{
+		ScenarioID: scenarioID,
+		Owner:      owner,
+		AcquiredOn: now,
+		RenewedOn:  now,
+		ExpiresOn:  now.Add(duration),
+	}
+
+	var version models.Version
+	doc, err := ll.c.read(scenarioID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return false, fmt.Errorf("unable to get lease on scenario [%s]: %w", scenarioID, err)
+	default:
+		var current models.Lease
+		if err := json.Unmarshal(doc.Source, &current); err != nil {
+			return false, fmt.Errorf("unable to parse lease on scenario [%s]: %w", scenarioID, err)
+		}
+
+		if current.Owner != owner && !current.IsExpired(now) {
+			return false, nil
+		}
+		if current.Owner == owner {
+			lease.AcquiredOn = current.AcquiredOn
+		}
+		version = doc.version()
+	}
+
+	if _, err := ll.c.write(scenarioID, lease, version); err != nil {
+		return false, fmt.Errorf("unable to persist lease on scenario [%s]: %w", scenarioID, err)
+	}
+
+	return true, nil
+}
+
+func (ll *localLeases) Release(scenarioID, owner string) error {
+	doc, err := ll.c.get(scenarioID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get lease on scenario [%s]: %w", scenarioID, err)
+	}
+
+	var current models.Lease
+	if err := json.Unmarshal(doc.Source, &current); err != nil {
+		return fmt.Errorf("unable to parse lease on scenario [%s]: %w", scenarioID, err)
+	}
+	if current.Owner != owner {
+		return nil
+	}
+
+	if err := ll.c.delete(scenarioID, doc.version()); err != nil && !errors.Is(err, ErrConflict) {
+		return fmt.Errorf("unable to release lease on scenario [%s]: %w", scenarioID, err)
+	}
+
+	return nil
+}
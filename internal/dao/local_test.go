@@ -0,0 +1,159 @@
+package dao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+func newTestLocalStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create local store: %v", err)
+	}
+
+	return store
+}
+
+func TestLocalScenarios(t *testing.T) {
+	store := newTestLocalStore(t)
+	scenarioDAO := store.Scenarios
+
+	if _, err := scenarioDAO.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("getting missing scenario: expected [%v], got [%v]", ErrNotFound, err)
+	}
+
+	for _, id := range []string{"b", "a"} {
+		s := &models.Scenario{ID: id}
+		if err := scenarioDAO.Save(s); err != nil {
+			t.Fatalf("unable to save scenario [%s]: %v", id, err)
+		}
+		if s.Version.IsZero() {
+			t.Fatalf("saved scenario [%s] has no version", id)
+		}
+	}
+
+	scenarios, err := scenarioDAO.ListAll()
+	if err != nil {
+		t.Fatalf("unable to list scenarios: %v", err)
+	}
+	if len(scenarios) != 2 || scenarios[0].ID != "a" || scenarios[1].ID != "b" {
+		t.Fatalf("expected scenarios [a b], got %+v", scenarios)
+	}
+
+	s, err := scenarioDAO.Get("a")
+	if err != nil {
+		t.Fatalf("unable to get scenario: %v", err)
+	}
+	stale := *s
+
+	s.DeploymentID = "deployment"
+	if err := scenarioDAO.Save(s); err != nil {
+		t.Fatalf("unable to update scenario: %v", err)
+	}
+	if !s.Version.IsNewerThan(stale.Version) {
+		t.Fatalf("updated scenario version [%+v] is not newer than [%+v]", s.Version, stale.Version)
+	}
+
+	// Stale updates and creating existing scenarios conflict
+	if err := scenarioDAO.Save(&stale); !errors.Is(err, ErrConflict) {
+		t.Fatalf("saving stale scenario: expected [%v], got [%v]", ErrConflict, err)
+	}
+	if err := scenarioDAO.Save(&models.Scenario{ID: "a"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("creating existing scenario: expected [%v], got [%v]", ErrConflict, err)
+	}
+
+	s, err = scenarioDAO.Get("a")
+	if err != nil {
+		t.Fatalf("unable to get scenario: %v", err)
+	}
+	if s.DeploymentID != "deployment" {
+		t.Fatalf("expected deployment ID [deployment], got [%s]", s.DeploymentID)
+	}
+}
+
+func TestLocalDeploymentConfigurations(t *testing.T) {
+	store := newTestLocalStore(t)
+	deploymentConfigDAO := store.DeploymentConfigurations
+
+	deploymentConfig := &models.DeploymentConfiguration{ID: "config", Template: []byte(`{"name":"test"}`)}
+	if err := deploymentConfigDAO.Save(deploymentConfig); err != nil {
+		t.Fatalf("unable to save deployment configuration: %v", err)
+	}
+
+	got, err := deploymentConfigDAO.Get("config")
+	if err != nil {
+		t.Fatalf("unable to get deployment configuration: %v", err)
+	}
+	if string(got.Template) != `{"name":"test"}` || got.Version != deploymentConfig.Version {
+		t.Fatalf("expected %+v, got %+v", deploymentConfig, got)
+	}
+
+	if err := deploymentConfigDAO.Save(&models.DeploymentConfiguration{ID: "config"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("creating existing deployment configuration: expected [%v], got [%v]", ErrConflict, err)
+	}
+}
+
+func TestLocalValidationResults(t *testing.T) {
+	store := newTestLocalStore(t)
+	validationResultDAO := store.ValidationResults
+
+	for _, scenarioID := range []string{"a", "b", "a"} {
+		result := &models.ValidationResult{ScenarioID: scenarioID, ValidatedOn: time.Now()}
+		if err := validationResultDAO.Save(result); err != nil {
+			t.Fatalf("unable to save validation result: %v", err)
+		}
+	}
+
+	results, err := validationResultDAO.ListAllForScenario("a")
+	if err != nil {
+		t.Fatalf("unable to list validation results: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 validation results for scenario [a], got %d", len(results))
+	}
+	for _, result := range results {
+		if result.ScenarioID != "a" {
+			t.Fatalf("listed validation result of scenario [%s] for scenario [a]", result.ScenarioID)
+		}
+	}
+}
+
+func TestLocalLeases(t *testing.T) {
+	store := newTestLocalStore(t)
+	leaseDAO := store.Leases
+
+	tryAcquire := func(owner string, duration time.Duration, expected bool) {
+		t.Helper()
+
+		acquired, err := leaseDAO.TryAcquire("scenario", owner, duration)
+		if err != nil {
+			t.Fatalf("unable to acquire lease: %v", err)
+		}
+		if acquired != expected {
+			t.Fatalf("expected [%s] acquiring lease to be %t, got %t", owner, expected, acquired)
+		}
+	}
+
+	tryAcquire("one", time.Hour, true)
+	tryAcquire("one", time.Hour, true)
+	tryAcquire("two", time.Hour, false)
+
+	// Only the owner releases the lease
+	if err := leaseDAO.Release("scenario", "two"); err != nil {
+		t.Fatalf("unable to release lease: %v", err)
+	}
+	tryAcquire("two", time.Hour, false)
+
+	if err := leaseDAO.Release("scenario", "one"); err != nil {
+		t.Fatalf("unable to release lease: %v", err)
+	}
+	tryAcquire("two", 0, true)
+
+	// Expired leases are taken over
+	tryAcquire("one", time.Hour, true)
+}
@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
 )
 
@@ -35,16 +37,17 @@ func (s *Scenario) ListAll() ([]models.Scenario, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		if res.StatusCode == 404 {
+		if res.StatusCode == http.StatusNotFound {
 			return scenarios, nil
 		}
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	res, err = s.stateConn.Search(
 		s.stateConn.Search.WithContext(context.Background()),
 		s.stateConn.Search.WithIndex(scenariosIndex),
 		s.stateConn.Search.WithSize(10000),
+		s.stateConn.Search.WithSeqNoPrimaryTerm(true),
 		// TODO: add active scenarios filter
 	)
 
@@ -54,14 +57,16 @@ func (s *Scenario) ListAll() ([]models.Scenario, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	var r struct {
 		Hits struct {
 			Hits []struct {
-				ID     string          `json:"_id"`
-				Source models.Scenario `json:"_source"`
+				ID          string          `json:"_id"`
+				SeqNo       int             `json:"_seq_no"`
+				PrimaryTerm int             `json:"_primary_term"`
+				Source      models.Scenario `json:"_source"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
@@ -71,6 +76,7 @@ func (s *Scenario) ListAll() ([]models.Scenario, error) {
 	}
 
 	for _, hit := range r.Hits.Hits {
+		hit.Source.Version = models.Version{SeqNo: hit.SeqNo, PrimaryTerm: hit.PrimaryTerm}
 		scenarios = append(scenarios, hit.Source)
 	}
 	return scenarios, nil
@@ -83,13 +89,19 @@ func (s *Scenario) Get(id string) (*models.Scenario, error) {
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("scenario [%s] %w", id, ErrNotFound)
+	}
+
 	if res.IsError() {
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	var r struct {
-		ID     string          `json:"_id"`
-		Source models.Scenario `json:"_source"`
+		ID          string          `json:"_id"`
+		SeqNo       int             `json:"_seq_no"`
+		PrimaryTerm int             `json:"_primary_term"`
+		Source      models.Scenario `json:"_source"`
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
@@ -97,6 +109,7 @@ func (s *Scenario) Get(id string) (*models.Scenario, error) {
 	}
 
 	scenario := r.Source
+	scenario.Version = models.Version{SeqNo: r.SeqNo, PrimaryTerm: r.PrimaryTerm}
 	return &scenario, nil
 }
 
@@ -106,19 +119,11 @@ func (s *Scenario) Save(scenario *models.Scenario) error {
 		return fmt.Errorf("unable to encode scenario [%s] as JSON: %w", scenario.ID, err)
 	}
 
-	res, err := s.stateConn.Index(
-		scenariosIndex,
-		&buf,
-		s.stateConn.Index.WithDocumentID(scenario.ID),
-	)
+	version, err := indexVersioned(s.stateConn, scenariosIndex, scenario.ID, &buf, scenario.Version)
 	if err != nil {
 		return fmt.Errorf("unable to persist scenario [%s]: %w", scenario.ID, err)
 	}
-	defer res.Body.Close()
-
-	if res.IsError() {
-		return handleESAPIErrorResponse(res)
-	}
 
+	scenario.Version = version
 	return nil
 }
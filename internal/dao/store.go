@@ -0,0 +1,126 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/secrets"
+)
+
+type ScenarioRepository interface {
+	ListAll() ([]models.Scenario, error)
+	Get(id string) (*models.Scenario, error)
+	Save(scenario *models.Scenario) error
+}
+
+type DeploymentConfigurationRepository interface {
+	ListAll() ([]models.DeploymentConfiguration, error)
+	Get(id string) (*models.DeploymentConfiguration, error)
+	Save(deploymentConfig *models.DeploymentConfiguration) error
+}
+
+type ValidationResultRepository interface {
+	ListAllForScenario(scenarioID string) ([]models.ValidationResult, error)
+	Save(result *models.ValidationResult) error
+}
+
+type LeaseRepository interface {
+	TryAcquire(scenarioID, owner string, duration time.Duration) (bool, error)
+	Release(scenarioID, owner string) error
+}
+
+type AuditLogRepository interface {
+	Save(event *models.AuditEvent) error
+}
+
+type Store struct {
+	Scenarios                ScenarioRepository
+	DeploymentConfigurations DeploymentConfigurationRepository
+	ValidationResults        ValidationResultRepository
+	Leases                   LeaseRepository
+	AuditLog                 AuditLogRepository
+
+	ping func() error
+}
+
+func (s *Store) Ping() error {
+	return s.ping()
+}
+
+func NewStore(cfg *config.Config) (*Store, error) {
+	var (
+		store *Store
+		err   error
+	)
+	switch cfg.GetStoreType() {
+	case config.StoreTypeElasticsearch:
+		var stateConn *es.Client
+		stateConn, err = NewStateClusterConnection(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		store = NewElasticsearchStore(stateConn)
+
+	case config.StoreTypeLocal:
+		store, err = NewLocalStore(cfg.GetStorePath())
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown store type [%s]", cfg.Store.Type)
+	}
+
+	kms, err := secrets.NewKMS(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up encryption: %w", err)
+	}
+	if kms == nil {
+		logging.Logger.Warn("encryption is not configured, deployment credentials are stored in plaintext")
+		return store, nil
+	}
+
+	store.Scenarios = NewEncryptedScenarios(store.Scenarios, kms)
+	return store, nil
+}
+
+func NewElasticsearchStore(stateConn *es.Client) *Store {
+	return &Store{
+		Scenarios:                NewScenario(stateConn),
+		DeploymentConfigurations: NewDeploymentConfiguration(stateConn),
+		ValidationResults:        NewValidationResult(stateConn),
+		Leases:                   NewLease(stateConn),
+		AuditLog:                 NewAuditLog(stateConn),
+		ping: func() error {
+			res, err := stateConn.Ping()
+			if err != nil {
+				return fmt.Errorf("unable to reach state cluster: %w", err)
+			}
+			defer res.Body.Close()
+
+			if res.IsError() {
+				return fmt.Errorf("unable to reach state cluster: [%s]", res.Status())
+			}
+
+			return nil
+		},
+	}
+}
+
+func NewStateClusterConnection(cfg *config.Config) (*es.Client, error) {
+	stateConn, err := es.NewClient(es.Config{
+		Addresses: []string{cfg.StateCluster.Url},
+		Username:  cfg.StateCluster.Username,
+		Password:  cfg.StateCluster.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection to state cluster: %w", err)
+	}
+
+	return stateConn, nil
+}
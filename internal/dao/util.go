@@ -3,23 +3,53 @@ package dao
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 
+	es "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
 )
 
-func handleESAPIErrorResponse(res *esapi.Response) error {
-	var e map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
-		return fmt.Errorf("error parsing the response body: %w", err)
+// indexVersioned indexes the given document with optimistic concurrency control.
+// A document with a zero version is created, and may not exist yet; otherwise,
+// the document is only replaced if it is still at the given version. It returns
+// the new version of the document, or a *ConflictError.
+func indexVersioned(stateConn *es.Client, index, id string, body io.Reader, version models.Version) (models.Version, error) {
+	opts := []func(*esapi.IndexRequest){
+		stateConn.Index.WithDocumentID(id),
+	}
+	if version.IsZero() {
+		opts = append(opts, stateConn.Index.WithOpType("create"))
 	} else {
-		if v, ok := e["message"]; ok {
-			return fmt.Errorf("[%s] %s", res.Status(), v)
-		} else {
-			return fmt.Errorf("[%s] %s: %s",
-				res.Status(),
-				e["error"].(map[string]interface{})["type"],
-				e["error"].(map[string]interface{})["reason"],
-			)
-		}
+		opts = append(opts,
+			stateConn.Index.WithIfSeqNo(version.SeqNo),
+			stateConn.Index.WithIfPrimaryTerm(version.PrimaryTerm),
+		)
+	}
+
+	res, err := stateConn.Index(index, body, opts...)
+	if err != nil {
+		return models.Version{}, err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return models.Version{}, &ConflictError{Index: index, ID: id}
+	}
+
+	if res.IsError() {
+		return models.Version{}, esutil.ResponseError(res)
+	}
+
+	var r struct {
+		SeqNo       int `json:"_seq_no"`
+		PrimaryTerm int `json:"_primary_term"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return models.Version{}, fmt.Errorf("error parsing the response body: %s", err)
+	}
+
+	return models.Version{SeqNo: r.SeqNo, PrimaryTerm: r.PrimaryTerm}, nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	es "github.com/elastic/go-elasticsearch/v7"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
 )
 
@@ -28,9 +29,22 @@ func NewValidationResult(stateConn *es.Client) *ValidationResult {
 func (vr *ValidationResult) ListAllForScenario(scenarioID string) ([]models.ValidationResult, error) {
 	var results []models.ValidationResult
 
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"scenario_id": scenarioID,
+			},
+		},
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("unable to encode query for validation results of scenario [%s]: %w", scenarioID, err)
+	}
+
 	res, err := vr.stateConn.Search(
 		vr.stateConn.Search.WithContext(context.Background()),
 		vr.stateConn.Search.WithIndex(validationResultsIndex),
+		vr.stateConn.Search.WithBody(&buf),
 		vr.stateConn.Search.WithSize(10000),
 	)
 
@@ -40,7 +54,7 @@ func (vr *ValidationResult) ListAllForScenario(scenarioID string) ([]models.Vali
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, handleESAPIErrorResponse(res)
+		return nil, esutil.ResponseError(res)
 	}
 
 	var r struct {
@@ -73,6 +87,7 @@ func (vr *ValidationResult) Save(result *models.ValidationResult) error {
 	res, err := vr.stateConn.Index(
 		validationResultsIndex,
 		&buf,
+		vr.stateConn.Index.WithOpType("create"),
 	)
 	if err != nil {
 		return fmt.Errorf("unable to persist validation result for scenario [%s]: %w", result.ScenarioID, err)
@@ -80,7 +95,7 @@ func (vr *ValidationResult) Save(result *models.ValidationResult) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return handleESAPIErrorResponse(res)
+		return esutil.ResponseError(res)
 	}
 
 	return nil
@@ -1,24 +1,117 @@
 package deployment
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/auth"
+	"github.com/elastic/cloud-sdk-go/pkg/client/deployments"
 	cloudModels "github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	es "github.com/elastic/go-elasticsearch/v7"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
 )
 
 type Credentials struct {
-	CloudID  string `json:"cloud_id"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	CloudID  string `json:"cloud_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// base64-encoded ID and key
+	APIKey string `json:"api_key,omitempty"`
+}
+
+const redacted = "[REDACTED]"
+
+func (c Credentials) Redacted() Credentials {
+	if c.Password != "" {
+		c.Password = redacted
+	}
+	if c.APIKey != "" {
+		c.APIKey = redacted
+	}
+
+	return c
+}
+
+func (c Credentials) IsZero() bool {
+	return c == Credentials{}
+}
+
+func (c Credentials) String() string {
+	r := c.Redacted()
+	return fmt.Sprintf("{cloud_id: %s, username: %s, password: %s, api_key: %s}", r.CloudID, r.Username, r.Password, r.APIKey)
+}
+
+func (c Credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	r := c.Redacted()
+	enc.AddString("cloud_id", r.CloudID)
+	enc.AddString("username", r.Username)
+	enc.AddString("password", r.Password)
+	enc.AddString("api_key", r.APIKey)
+
+	return nil
+}
+
+func NewClient(creds Credentials) (*es.Client, error) {
+	cfg := es.Config{
+		CloudID: creds.CloudID,
+	}
+	if creds.APIKey != "" {
+		cfg.APIKey = creds.APIKey
+	} else {
+		cfg.Username = creds.Username
+		cfg.Password = creds.Password
+	}
+
+	return es.NewClient(cfg)
 }
 
 type OutVars struct {
+	DeploymentID          string
 	DeploymentCredentials Credentials
 	ClusterIDs            []string
 }
 
+const resourceStatusStarted = "started"
+
+func NewAPI(url, apiKey string) (*api.API, error) {
+	essConn, err := api.NewAPI(api.Config{
+		Host:       url,
+		Client:     new(http.Client),
+		AuthWriter: auth.APIKey(apiKey),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to Elastic Cloud API at [%s]: %w", url, err)
+	}
+
+	return essConn, nil
+}
+
+func ValidateDeployment(api *api.API, name string, req *cloudModels.DeploymentCreateRequest) error {
+	req.Name = name
+	_, _, _, err := api.V1API.Deployments.CreateDeployment(
+		deployments.NewCreateDeploymentParams().
+			WithValidateOnly(ec.Bool(true)).
+			WithBody(req),
+		api.AuthWriter,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to validate deployment [%s]: %w", name, apierror.Wrap(err))
+	}
+
+	return nil
+}
+
 func CreateDeployment(api *api.API, name string, req *cloudModels.DeploymentCreateRequest) (OutVars, error) {
 	var out OutVars
 
@@ -31,6 +124,9 @@ func CreateDeployment(api *api.API, name string, req *cloudModels.DeploymentCrea
 		return out, fmt.Errorf("unable to create deployment [%s]: %w", req.Name, err)
 	}
 
+	if resp.ID != nil {
+		out.DeploymentID = *resp.ID
+	}
 	out.ClusterIDs = getClusterIDs(resp.Resources)
 	out.DeploymentCredentials = *getDeploymentCredentials(resp.Resources)
 
@@ -49,20 +145,101 @@ func DeleteDeployment(api *api.API, id string) error {
 }
 
 func CheckIfDeploymentExists(api *api.API, name string) (bool, error) {
+	id, err := GetDeploymentID(api, name)
+	if err != nil {
+		return false, err
+	}
+
+	return id != "", nil
+}
+
+func GetDeploymentID(api *api.API, name string) (string, error) {
 	resp, err := deploymentapi.List(deploymentapi.ListParams{
 		API: api,
 	})
 	if err != nil {
-		return false, fmt.Errorf("unable to list deployments: %w", err)
+		return "", fmt.Errorf("unable to list deployments: %w", err)
 	}
 
 	for _, deployment := range resp.Deployments {
-		if deployment.Name != nil && *deployment.Name == name {
-			return true, nil
+		if deployment.Name != nil && *deployment.Name == name && deployment.ID != nil {
+			return *deployment.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+func IsHealthy(api *api.API, id string) (bool, error) {
+	resp, err := deploymentapi.Get(deploymentapi.GetParams{
+		API:          api,
+		DeploymentID: id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("unable to get deployment [%s]: %w", id, err)
+	}
+
+	if resp.Resources == nil {
+		return false, nil
+	}
+
+	for _, resource := range resp.Resources.Elasticsearch {
+		info := resource.Info
+		if info == nil || !isResourceHealthy(info.Healthy, info.Status) ||
+			(info.PlanInfo != nil && info.PlanInfo.Pending != nil) {
+			return false, nil
 		}
 	}
 
-	return false, nil
+	for _, resource := range resp.Resources.Kibana {
+		info := resource.Info
+		if info == nil || !isResourceHealthy(info.Healthy, info.Status) ||
+			(info.PlanInfo != nil && info.PlanInfo.Pending != nil) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Errors getting the deployment's health are logged and polling continues.
+func WaitForHealthy(ctx context.Context, api *api.API, id string, timeout, pollInterval time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		healthy, err := IsHealthy(api, id)
+		if err != nil {
+			logging.Logger.Warn("unable to get deployment health, retrying",
+				zap.String("deployment", id),
+				zap.Error(err),
+			)
+			lastErr = err
+		}
+		if healthy {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			if lastErr != nil {
+				return fmt.Errorf("deployment [%s] did not become healthy within [%s]: %w", id, timeout, lastErr)
+			}
+			return fmt.Errorf("deployment [%s] did not become healthy within [%s]", id, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func isResourceHealthy(healthy *bool, status *string) bool {
+	return healthy != nil && *healthy && status != nil && *status == resourceStatusStarted
 }
 
 func getClusterIDs(resources []*cloudModels.DeploymentResource) []string {
@@ -0,0 +1,54 @@
+package esutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+
+	ErrConflict = errors.New("conflict")
+)
+
+func ResponseError(res *esapi.Response) error {
+	var sentinel error
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		sentinel = ErrNotFound
+	case http.StatusConflict:
+		sentinel = ErrConflict
+	}
+
+	err := responseError(res)
+	if sentinel != nil {
+		return fmt.Errorf("%s: %w", err, sentinel)
+	}
+
+	return err
+}
+
+func responseError(res *esapi.Response) error {
+	var e map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
+		return fmt.Errorf("[%s] error parsing the response body: %s", res.Status(), err)
+	}
+
+	if v, ok := e["message"]; ok {
+		return fmt.Errorf("[%s] %v", res.Status(), v)
+	}
+
+	// Errors are either objects with a type and reason, or plain strings
+	switch esErr := e["error"].(type) {
+	case map[string]interface{}:
+		return fmt.Errorf("[%s] %v: %v", res.Status(), esErr["type"], esErr["reason"])
+	case string:
+		return fmt.Errorf("[%s] %s", res.Status(), esErr)
+	}
+
+	return fmt.Errorf("[%s] %v", res.Status(), e)
+}
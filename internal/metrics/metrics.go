@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const namespace = "ecbgd"
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+
+	resultPass  = "pass"
+	resultFail  = "fail"
+	resultError = "error"
+)
+
+var (
+	runningScenarios = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "running_scenarios",
+		Help:      "Number of scenarios run by this replica.",
+	})
+
+	exerciseOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exercise_operations_total",
+		Help:      "Number of requests fired against golden deployments, by scenario, operation and result.",
+	}, []string{"scenario", "operation", "result"})
+
+	exerciseOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "exercise_operation_duration_seconds",
+		Help:      "Latency of requests fired against golden deployments, by scenario and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"scenario", "operation"})
+
+	validationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "validation_duration_seconds",
+		Help:      "Time taken to validate scenarios, by scenario.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"scenario"})
+
+	validationResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_results_total",
+		Help:      "Number of validations, by scenario, metric and result (pass, fail, or error if the metric could not be computed).",
+	}, []string{"scenario", "metric", "result"})
+
+	validationActual = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "validation_actual",
+		Help:      "Actual value of the metric at the last validation, by scenario and metric.",
+	}, []string{"scenario", "metric"})
+
+	validationExpectedMin = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "validation_expected_min",
+		Help:      "Minimum expected value of the metric, by scenario and metric.",
+	}, []string{"scenario", "metric"})
+
+	validationExpectedMax = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "validation_expected_max",
+		Help:      "Maximum expected value of the metric, by scenario and metric.",
+	}, []string{"scenario", "metric"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Number of alert notifications sent, by notifier and result.",
+	}, []string{"notifier", "result"})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		runningScenarios,
+		exerciseOperations,
+		exerciseOperationDuration,
+		validationDuration,
+		validationResults,
+		validationActual,
+		validationExpectedMin,
+		validationExpectedMax,
+		notifications,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func SetRunningScenarios(n int) {
+	runningScenarios.Set(float64(n))
+}
+
+func ObserveExerciseOperation(scenarioID, operation string, duration time.Duration, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+
+	exerciseOperations.WithLabelValues(scenarioID, operation, result).Inc()
+	exerciseOperationDuration.WithLabelValues(scenarioID, operation).Observe(duration.Seconds())
+}
+
+func ObserveValidation(result *models.ValidationResult, duration time.Duration) {
+	validationDuration.WithLabelValues(result.ScenarioID).Observe(duration.Seconds())
+
+	for metric, r := range result.Metrics() {
+		if r.Error != "" {
+			// The actual value is unknown, so the last known one is kept
+			validationResults.WithLabelValues(result.ScenarioID, metric, resultError).Inc()
+			continue
+		}
+
+		outcome := resultPass
+		if !r.IsValid {
+			outcome = resultFail
+		}
+
+		validationResults.WithLabelValues(result.ScenarioID, metric, outcome).Inc()
+		validationActual.WithLabelValues(result.ScenarioID, metric).Set(r.Actual)
+		validationExpectedMin.WithLabelValues(result.ScenarioID, metric).Set(r.Expected.Min)
+		validationExpectedMax.WithLabelValues(result.ScenarioID, metric).Set(r.Expected.Max)
+	}
+}
+
+// ObserveNotification records alerts being sent to a notifier.
+func ObserveNotification(notifier string, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+
+	notifications.WithLabelValues(notifier, result).Inc()
+}
+
+// ForgetScenario removes the gauges of a scenario that is no longer run by this
+// replica.
+func ForgetScenario(scenarioID string) {
+	for metric := range (&models.ValidationResult{}).Metrics() {
+		validationActual.DeleteLabelValues(scenarioID, metric)
+		validationExpectedMin.DeleteLabelValues(scenarioID, metric)
+		validationExpectedMax.DeleteLabelValues(scenarioID, metric)
+	}
+}
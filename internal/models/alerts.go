@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Alerts struct {
+	Notifiers []string `json:"notifiers,omitempty"`
+
+	RepeatIntervalSeconds int `json:"repeat_interval_seconds,omitempty"`
+
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+func (a *Alerts) GetRepeatInterval(defaultInterval time.Duration) time.Duration {
+	if a == nil || a.RepeatIntervalSeconds <= 0 {
+		return defaultInterval
+	}
+
+	return time.Duration(a.RepeatIntervalSeconds) * time.Second
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AuditEvent records a request that changed, or attempted to change, the
+// service's state through the API.
+type AuditEvent struct {
+	Timestamp time.Time `json:"@timestamp"`
+
+	// Principal is who made the request, and how they authenticated. It is
+	// empty if the request was not authenticated.
+	Principal  string `json:"principal,omitempty"`
+	Role       string `json:"role,omitempty"`
+	AuthMethod string `json:"auth_method,omitempty"`
+
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	ClientIP string `json:"client_ip"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty" es:"text"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+)
+
+const (
+	defaultDataStream      = "gds-workload"
+	defaultRolloverMaxAge  = "1d"
+	defaultRolloverMaxSize = "1gb"
+	defaultDeleteAfterDays = 7
+)
+
+type Data struct {
+	DataStream string `json:"data_stream"`
+	Rollover   struct {
+		MaxAge  string `json:"max_age"`
+		MaxSize string `json:"max_size"`
+	} `json:"rollover"`
+	DeleteAfterDays int `json:"delete_after_days"`
+}
+
+func (d *Data) GetDataStream() string {
+	if d.DataStream == "" {
+		return defaultDataStream
+	}
+
+	return d.DataStream
+}
+
+func (d *Data) GetILMPolicyName() string {
+	return d.GetDataStream()
+}
+
+func (d *Data) GetIndexTemplateName() string {
+	return d.GetDataStream()
+}
+
+func (d *Data) ILMPolicy() map[string]interface{} {
+	maxAge := d.Rollover.MaxAge
+	if maxAge == "" {
+		maxAge = defaultRolloverMaxAge
+	}
+
+	maxSize := d.Rollover.MaxSize
+	if maxSize == "" {
+		maxSize = defaultRolloverMaxSize
+	}
+
+	deleteAfterDays := d.DeleteAfterDays
+	if deleteAfterDays <= 0 {
+		deleteAfterDays = defaultDeleteAfterDays
+	}
+
+	return map[string]interface{}{
+		"policy": map[string]interface{}{
+			"phases": map[string]interface{}{
+				"hot": map[string]interface{}{
+					"actions": map[string]interface{}{
+						"rollover": map[string]interface{}{
+							"max_age":  maxAge,
+							"max_size": maxSize,
+						},
+					},
+				},
+				"delete": map[string]interface{}{
+					"min_age": fmt.Sprintf("%dd", deleteAfterDays),
+					"actions": map[string]interface{}{
+						"delete": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *Data) IndexTemplate() map[string]interface{} {
+	return map[string]interface{}{
+		"index_patterns": []string{d.GetDataStream()},
+		"data_stream":    map[string]interface{}{},
+		"template": map[string]interface{}{
+			"settings": map[string]interface{}{
+				"index": map[string]interface{}{
+					"lifecycle": map[string]interface{}{
+						"name": d.GetILMPolicyName(),
+					},
+				},
+			},
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"@timestamp": map[string]string{"type": "date"},
+					"message":    map[string]string{"type": "text"},
+					"metric": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"count": map[string]string{"type": "long"},
+							"sum":   map[string]string{"type": "long"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -14,7 +14,10 @@ type DeploymentConfiguration struct {
 		Type    string      `json:"type"`
 		Default interface{} `json:"default"`
 	} `json:"vars"`
-	Template json.RawMessage `json:"template"`
+	Template json.RawMessage `json:"template" es:"dynamic"`
+
+	// Version is the revision of the configuration in the state cluster
+	Version Version `json:"-"`
 }
 
 func (dt *DeploymentConfiguration) ToDeploymentCreateRequest(overrideVars map[string]interface{}) (*models.DeploymentCreateRequest, error) {
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Lease grants a single service replica the right to run a scenario until the
+// lease expires. The holder renews the lease while it runs the scenario; other
+// replicas take over once the lease has expired.
+type Lease struct {
+	ScenarioID string    `json:"scenario_id"`
+	Owner      string    `json:"owner"`
+	AcquiredOn time.Time `json:"acquired_on"`
+	RenewedOn  time.Time `json:"renewed_on"`
+	ExpiresOn  time.Time `json:"expires_on"`
+}
+
+// IsExpired returns whether the lease has expired as of the given time.
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresOn)
+}
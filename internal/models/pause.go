@@ -0,0 +1,107 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type PausedInterval struct {
+	From              time.Time  `json:"from"`
+	To                *time.Time `json:"to,omitempty"`
+	Reason            string     `json:"reason,omitempty" es:"text"`
+	ValidationsPaused bool       `json:"validations_paused"`
+}
+
+func (s *Scenario) Pause(reason string, pauseValidations bool) {
+	s.PausedIntervals = append(s.PausedIntervals, PausedInterval{
+		From:              time.Now(),
+		Reason:            reason,
+		ValidationsPaused: pauseValidations,
+	})
+}
+
+func (s *Scenario) Unpause() {
+	if interval := s.currentPausedInterval(); interval != nil {
+		now := time.Now()
+		interval.To = &now
+	}
+}
+
+func (s *Scenario) AreValidationsPaused() bool {
+	interval := s.currentPausedInterval()
+	return interval != nil && interval.ValidationsPaused
+}
+
+func (s *Scenario) currentPausedInterval() *PausedInterval {
+	if len(s.PausedIntervals) == 0 {
+		return nil
+	}
+
+	interval := &s.PausedIntervals[len(s.PausedIntervals)-1]
+	if interval.To != nil {
+		return nil
+	}
+
+	return interval
+}
+
+func (s *Scenario) pausedIntervalsBetween(from, to time.Time) ([]PausedInterval, time.Duration) {
+	var intervals []PausedInterval
+	var total time.Duration
+
+	for _, interval := range s.PausedIntervals {
+		end := to
+		if interval.To != nil && interval.To.Before(to) {
+			end = *interval.To
+		}
+		start := interval.From
+		if start.Before(from) {
+			start = from
+		}
+
+		if !start.Before(end) {
+			continue
+		}
+
+		clipped := interval
+		clipped.From = start
+		clipped.To = &end
+		intervals = append(intervals, clipped)
+		total += end.Sub(start)
+	}
+
+	return intervals, total
+}
+
+var dateMathPattern = regexp.MustCompile(`^now(?:-(\d+)([smhdw]))?$`)
+
+// resolveDateMath only supports "now", "now-<n><unit>" and RFC 3339 timestamps.
+func resolveDateMath(expr string, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, expr); err == nil {
+		return t, true
+	}
+
+	matches := dateMathPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return time.Time{}, false
+	}
+	if matches[1] == "" {
+		return now, true
+	}
+
+	n, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	units := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	return now.Add(-time.Duration(n) * units[matches[2]]), true
+}
@@ -5,11 +5,14 @@ import (
 	"time"
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/deployment"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/secrets"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/usage"
 
 	"github.com/google/uuid"
 )
 
+const defaultWorkloadIndex = "foo"
+
 type FloatRange struct {
 	Min float64 `json:"min" binding:"required"`
 	Max float64 `json:"max" binding:"required"`
@@ -21,7 +24,7 @@ type FloatValidationResult struct {
 	Actual   float64    `json:"actual"`
 	Expected FloatRange `json:"expected"`
 
-	Error string `json:"error"`
+	Error string `json:"error,omitempty" es:"text"`
 }
 
 type Scenario struct {
@@ -29,13 +32,12 @@ type Scenario struct {
 		ID        string                 `json:"id" binding:"required"`
 		Variables map[string]interface{} `json:"vars,omitempty"`
 	} `json:"deployment_config" binding:"required"`
-	Workload struct {
-		StartOffsetSeconds   int `json:"start_offset_seconds"`
-		MinIntervalSeconds   int `json:"min_interval_seconds"`
-		MaxIntervalSeconds   int `json:"max_interval_seconds"`
-		MaxRequestsPerSecond int `json:"max_requests_per_second"`
-		IndexToSearchRatio   int `json:"index_to_search_ratio"`
-	} `json:"workload"`
+	Setup struct {
+		Assets []SetupAsset `json:"assets,omitempty"`
+	} `json:"setup"`
+	Workload    Workload   `json:"workload"`
+	Data        *Data      `json:"data,omitempty"`
+	Snapshots   *Snapshots `json:"snapshots,omitempty"`
 	Validations struct {
 		FrequencySeconds int `json:"frequency_seconds"`
 		Query            struct {
@@ -50,13 +52,34 @@ type Scenario struct {
 			SnapshotAPIRequestsCount FloatRange `json:"snapshot_api_requests_count" binding:"required"`
 		} `json:"expectations"`
 	} `json:"validations"`
+	Alerts *Alerts `json:"alerts,omitempty"`
 
 	ID                    string                 `json:"id"`
+	Status                ScenarioState          `json:"status,omitempty"`
+	Transitions           []StateTransition      `json:"transitions,omitempty"`
+	DeploymentID          string                 `json:"deployment_id,omitempty"`
 	ClusterIDs            []string               `json:"cluster_ids"`
 	DeploymentCredentials deployment.Credentials `json:"deployment_credentials"`
 
-	StartedOn *time.Time `json:"started_on,omitempty"`
-	StoppedOn *time.Time `json:"stopped_on,omitempty"`
+	EncryptedCredentials *secrets.Envelope `json:"encrypted_credentials,omitempty" es:"disabled"`
+
+	// AppliedSetupAssets maps setup asset keys to the hashes of their contents
+	AppliedSetupAssets map[string]string `json:"applied_setup_assets,omitempty" es:"disabled"`
+
+	PausedIntervals []PausedInterval `json:"paused_intervals,omitempty"`
+
+	StartedOn         *time.Time `json:"started_on,omitempty"`
+	ExerciseStartedOn *time.Time `json:"exercise_started_on,omitempty"`
+	StoppedOn         *time.Time `json:"stopped_on,omitempty"`
+
+	Version Version `json:"-"`
+}
+
+func (s Scenario) Redacted() Scenario {
+	s.DeploymentCredentials = s.DeploymentCredentials.Redacted()
+	s.EncryptedCredentials = nil
+
+	return s
 }
 
 func (s *Scenario) IsStarted() bool {
@@ -74,6 +97,14 @@ func (s *Scenario) Validate(usageConn *usage.Connection) *ValidationResult {
 	result.ScenarioID = s.ID
 	result.ValidatedOn = time.Now()
 
+	from, fromOK := resolveDateMath(q.From, result.ValidatedOn)
+	to, toOK := resolveDateMath(q.To, result.ValidatedOn)
+	if fromOK && toOK {
+		intervals, paused := s.pausedIntervalsBetween(from, to)
+		result.PausedIntervals = intervals
+		result.PausedSeconds = paused.Seconds()
+	}
+
 	s.validateInstanceCapacity(usageConn, q, result)
 	s.validateDataInterNode(usageConn, q, result)
 	s.validateDataOut(usageConn, q, result)
@@ -97,6 +128,27 @@ func (s *Scenario) GetDeploymentName() string {
 	return fmt.Sprintf("golden-%s", s.ID)
 }
 
+func (s *Scenario) GetExerciseStartTime() time.Time {
+	if s.ExerciseStartedOn != nil {
+		return *s.ExerciseStartedOn
+	}
+
+	startedOn := time.Now()
+	if s.StartedOn != nil {
+		startedOn = *s.StartedOn
+	}
+
+	return startedOn.Add(time.Duration(s.Workload.StartOffsetSeconds) * time.Second)
+}
+
+func (s *Scenario) GetWorkloadTarget() string {
+	if s.Data == nil {
+		return defaultWorkloadIndex
+	}
+
+	return s.Data.GetDataStream()
+}
+
 func (s *Scenario) GetValidationFrequency() time.Duration {
 	return time.Duration(s.Validations.FrequencySeconds) * time.Second
 }
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type ScenarioState string
+
+const (
+	ScenarioStatePending      ScenarioState = "pending"
+	ScenarioStateProvisioning ScenarioState = "provisioning"
+	ScenarioStateExercising   ScenarioState = "exercising"
+	ScenarioStatePaused       ScenarioState = "paused"
+	ScenarioStateStopping     ScenarioState = "stopping"
+	ScenarioStateStopped      ScenarioState = "stopped"
+	ScenarioStateFailed       ScenarioState = "failed"
+)
+
+var scenarioStateTransitions = map[ScenarioState][]ScenarioState{
+	ScenarioStatePending: {
+		ScenarioStateProvisioning, ScenarioStateStopping, ScenarioStateFailed,
+	},
+	ScenarioStateProvisioning: {
+		ScenarioStateProvisioning,
+		ScenarioStateExercising, ScenarioStateStopping, ScenarioStateFailed,
+	},
+	ScenarioStateExercising: {
+		ScenarioStateProvisioning,
+		ScenarioStatePaused, ScenarioStateStopping, ScenarioStateFailed,
+	},
+	ScenarioStatePaused: {
+		// Paused scenarios that aren't running are provisioned again when resumed
+		ScenarioStateProvisioning,
+		ScenarioStateExercising, ScenarioStateStopping, ScenarioStateFailed,
+	},
+	ScenarioStateStopping: {
+		ScenarioStateStopped, ScenarioStateFailed,
+	},
+	ScenarioStateStopped: {},
+	ScenarioStateFailed: {
+		ScenarioStateProvisioning, ScenarioStateStopping,
+	},
+}
+
+type StateTransition struct {
+	From   ScenarioState `json:"from,omitempty"`
+	To     ScenarioState `json:"to"`
+	On     time.Time     `json:"on"`
+	Reason string        `json:"reason,omitempty" es:"text"`
+}
+
+type InvalidTransitionError struct {
+	ScenarioID string
+	From       ScenarioState
+	To         ScenarioState
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("scenario [%s] cannot transition from state [%s] to state [%s]", e.ScenarioID, e.From, e.To)
+}
+
+func (s *Scenario) GetState() ScenarioState {
+	if s.Status == "" {
+		return ScenarioStatePending
+	}
+
+	return s.Status
+}
+
+func (s *Scenario) CanTransitionTo(to ScenarioState) bool {
+	for _, allowed := range scenarioStateTransitions[s.GetState()] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Scenario) TransitionTo(to ScenarioState, reason string) error {
+	from := s.GetState()
+	isInitial := s.Status == "" && to == ScenarioStatePending
+	if !isInitial && !s.CanTransitionTo(to) {
+		return &InvalidTransitionError{ScenarioID: s.ID, From: from, To: to}
+	}
+
+	transition := StateTransition{
+		To:     to,
+		On:     time.Now(),
+		Reason: reason,
+	}
+	if s.Status != "" {
+		transition.From = from
+	}
+
+	s.Status = to
+	s.Transitions = append(s.Transitions, transition)
+
+	return nil
+}
+
+func (s *Scenario) IsResumable() bool {
+	switch s.GetState() {
+	case ScenarioStatePending, ScenarioStateProvisioning, ScenarioStateExercising:
+		return true
+	default:
+		return false
+	}
+}
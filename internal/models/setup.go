@@ -0,0 +1,114 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+type SetupAssetType string
+
+const (
+	SetupAssetIndexTemplate  SetupAssetType = "index_template"
+	SetupAssetIngestPipeline SetupAssetType = "ingest_pipeline"
+	SetupAssetILMPolicy      SetupAssetType = "ilm_policy"
+	SetupAssetSeedData       SetupAssetType = "seed_data"
+)
+
+// SetupAsset is read from Body, or from the file at Path within the assets
+// directory. Seed data is NDJSON, loaded into the index named Name.
+type SetupAsset struct {
+	Type SetupAssetType  `json:"type" binding:"required"`
+	Name string          `json:"name" binding:"required"`
+	Body json.RawMessage `json:"body,omitempty"`
+	Path string          `json:"path,omitempty"`
+}
+
+func (a *SetupAsset) Key() string {
+	return fmt.Sprintf("%s/%s", a.Type, a.Name)
+}
+
+func (a *SetupAsset) Contents(assetsDir string) ([]byte, error) {
+	if a.Path != "" {
+		path, err := a.resolvePath(assetsDir)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read setup asset [%s] from file [%s]: %w", a.Key(), a.Path, err)
+		}
+		return contents, nil
+	}
+
+	if len(a.Body) == 0 {
+		return nil, fmt.Errorf("setup asset [%s] has neither a body nor a path", a.Key())
+	}
+
+	if a.Type == SetupAssetSeedData {
+		var ndjson string
+		if err := json.Unmarshal(a.Body, &ndjson); err == nil {
+			return []byte(ndjson), nil
+		}
+	}
+
+	return a.Body, nil
+}
+
+func (a *SetupAsset) Validate() error {
+	switch a.Type {
+	case SetupAssetIndexTemplate, SetupAssetIngestPipeline, SetupAssetILMPolicy, SetupAssetSeedData:
+	default:
+		return fmt.Errorf("unknown type [%s] for setup asset [%s]", a.Type, a.Name)
+	}
+
+	if a.Path == "" {
+		return nil
+	}
+
+	cleaned := filepath.Clean(a.Path)
+	if filepath.IsAbs(a.Path) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path [%s] of setup asset [%s] must be relative to the assets directory", a.Path, a.Key())
+	}
+
+	return nil
+}
+
+// Symbolic links may not lead outside of the assets directory.
+func (a *SetupAsset) resolvePath(assetsDir string) (string, error) {
+	if assetsDir == "" {
+		return "", fmt.Errorf("setup asset [%s] cannot be read from a file: no assets directory is configured", a.Key())
+	}
+
+	if err := a.Validate(); err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.EvalSymlinks(assetsDir)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve assets directory [%s]: %w", assetsDir, err)
+	}
+
+	// The error would reveal the assets directory
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, a.Path))
+	if err != nil {
+		return "", fmt.Errorf("unable to read setup asset [%s] from file [%s]: file does not exist or cannot be accessed", a.Key(), a.Path)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path [%s] of setup asset [%s] leads outside of the assets directory", a.Path, a.Key())
+	}
+
+	return path, nil
+}
+
+func Hash(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,61 @@
+package models
+
+const (
+	defaultSnapshotPolicy     = "cloud-snapshot-policy" // created by Elastic Cloud in every deployment
+	defaultSnapshotRepository = "found-snapshots"
+	defaultSnapshotName       = "<cloud-snapshot-{now/d}>"
+	defaultSnapshotSchedule   = "0 */30 * * * ?"
+)
+
+type Snapshots struct {
+	Repository string   `json:"repository"`
+	Schedule   string   `json:"schedule"`
+	Indices    []string `json:"indices"`
+	Retention  struct {
+		ExpireAfter string `json:"expire_after"`
+		MinCount    int    `json:"min_count"`
+		MaxCount    int    `json:"max_count"`
+	} `json:"retention"`
+}
+
+func (sn *Snapshots) GetPolicyName() string {
+	return defaultSnapshotPolicy
+}
+
+func (sn *Snapshots) SLMPolicy() map[string]interface{} {
+	repository := sn.Repository
+	if repository == "" {
+		repository = defaultSnapshotRepository
+	}
+
+	schedule := sn.Schedule
+	if schedule == "" {
+		schedule = defaultSnapshotSchedule
+	}
+
+	indices := sn.Indices
+	if len(indices) == 0 {
+		indices = []string{"*"}
+	}
+
+	retention := map[string]interface{}{}
+	if sn.Retention.ExpireAfter != "" {
+		retention["expire_after"] = sn.Retention.ExpireAfter
+	}
+	if sn.Retention.MinCount > 0 {
+		retention["min_count"] = sn.Retention.MinCount
+	}
+	if sn.Retention.MaxCount > 0 {
+		retention["max_count"] = sn.Retention.MaxCount
+	}
+
+	return map[string]interface{}{
+		"name":       defaultSnapshotName,
+		"schedule":   schedule,
+		"repository": repository,
+		"config": map[string]interface{}{
+			"indices": indices,
+		},
+		"retention": retention,
+	}
+}
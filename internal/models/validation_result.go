@@ -7,9 +7,25 @@ type ValidationResult struct {
 
 	ValidatedOn time.Time `json:"@timestamp"`
 
+	// PausedIntervals are the windows within the validation query's time range
+	// during which the scenario's workload was paused.
+	PausedIntervals []PausedInterval `json:"paused_intervals,omitempty"`
+	PausedSeconds   float64          `json:"paused_seconds"`
+
 	InstanceCapacityGBHours  FloatValidationResult `json:"instance_capacity_gb_hours"`
 	DataOutGB                FloatValidationResult `json:"data_out_gb"`
 	DataInterNodeGB          FloatValidationResult `json:"data_internode_gb"`
 	SnapshotStorageSizeGB    FloatValidationResult `json:"snapshot_storage_size_gb"`
 	SnapshotAPIRequestsCount FloatValidationResult `json:"snapshot_api_requests_count"`
 }
+
+// Metrics returns the result of validating each metric, by the metric's name.
+func (r *ValidationResult) Metrics() map[string]FloatValidationResult {
+	return map[string]FloatValidationResult{
+		"instance_capacity_gb_hours":  r.InstanceCapacityGBHours,
+		"data_out_gb":                 r.DataOutGB,
+		"data_internode_gb":           r.DataInterNodeGB,
+		"snapshot_storage_size_gb":    r.SnapshotStorageSizeGB,
+		"snapshot_api_requests_count": r.SnapshotAPIRequestsCount,
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// Version is used for optimistic concurrency control and is not persisted as
+// part of the document.
+type Version struct {
+	SeqNo       int
+	PrimaryTerm int
+}
+
+func (v Version) IsZero() bool {
+	return v.PrimaryTerm == 0
+}
+
+func (v Version) IsNewerThan(other Version) bool {
+	if v.PrimaryTerm != other.PrimaryTerm {
+		return v.PrimaryTerm > other.PrimaryTerm
+	}
+
+	return v.SeqNo > other.SeqNo
+}
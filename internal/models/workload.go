@@ -0,0 +1,166 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	defaultWorkers      = 1
+	defaultBurstSeconds = 1
+)
+
+type RateCurvePoint struct {
+	Hour       float64 `json:"hour"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type Workload struct {
+	StartOffsetSeconds      int              `json:"start_offset_seconds"`
+	MinIntervalSeconds      int              `json:"min_interval_seconds"`
+	MaxIntervalSeconds      int              `json:"max_interval_seconds"`
+	BurstSeconds            int              `json:"burst_seconds"`
+	MaxRequestsPerSecond    int              `json:"max_requests_per_second"`
+	TargetRequestsPerSecond float64          `json:"target_requests_per_second"`
+	Jitter                  float64          `json:"jitter"`
+	Workers                 int              `json:"workers"`
+	RateCurve               []RateCurvePoint `json:"rate_curve,omitempty"`
+	IndexToSearchRatio      int              `json:"index_to_search_ratio"`
+}
+
+func (w *Workload) GetWorkers() int {
+	if w.Workers <= 0 {
+		return defaultWorkers
+	}
+
+	return w.Workers
+}
+
+// Workloads that only define a maximum rate keep the mean of the previous
+// "random up to max" behavior.
+func (w *Workload) GetTargetRequestsPerSecond() float64 {
+	if w.TargetRequestsPerSecond > 0 {
+		return w.TargetRequestsPerSecond
+	}
+
+	return float64(w.MaxRequestsPerSecond) / 2
+}
+
+func (w *Workload) GetBurst() int {
+	if w.MaxRequestsPerSecond > 0 {
+		return w.MaxRequestsPerSecond
+	}
+
+	return int(math.Ceil(w.GetTargetRequestsPerSecond()))
+}
+
+func (w *Workload) IsCycling() bool {
+	return w.MaxIntervalSeconds > 0
+}
+
+func (w *Workload) GetBurstDuration() time.Duration {
+	if w.BurstSeconds <= 0 {
+		return defaultBurstSeconds * time.Second
+	}
+
+	return time.Duration(w.BurstSeconds) * time.Second
+}
+
+func (w *Workload) NextIdleInterval() time.Duration {
+	min, max := w.MinIntervalSeconds, w.MaxIntervalSeconds
+	if min < 0 {
+		min = 0
+	}
+	if max <= min {
+		return time.Duration(min) * time.Second
+	}
+
+	return time.Duration(min+rand.Intn(max-min+1)) * time.Second
+}
+
+func (w *Workload) Validate() error {
+	if w.Workers < 0 {
+		return fmt.Errorf("invalid workload: workers must be positive, got [%d]", w.Workers)
+	}
+
+	if w.Jitter < 0 || w.Jitter > 1 {
+		return fmt.Errorf("invalid workload: jitter must be between 0 and 1, got [%g]", w.Jitter)
+	}
+
+	if w.TargetRequestsPerSecond < 0 {
+		return fmt.Errorf("invalid workload: target_requests_per_second must be positive, got [%g]", w.TargetRequestsPerSecond)
+	}
+
+	if w.MinIntervalSeconds < 0 {
+		return fmt.Errorf("invalid workload: min_interval_seconds must be positive, got [%d]", w.MinIntervalSeconds)
+	}
+
+	if w.MaxIntervalSeconds > 0 && w.MinIntervalSeconds > w.MaxIntervalSeconds {
+		return fmt.Errorf("invalid workload: min_interval_seconds [%d] must not exceed max_interval_seconds [%d]",
+			w.MinIntervalSeconds, w.MaxIntervalSeconds)
+	}
+
+	for _, p := range w.RateCurve {
+		if p.Hour < 0 || p.Hour >= 24 {
+			return fmt.Errorf("invalid workload: rate_curve hours must be between 0 and 24, got [%g]", p.Hour)
+		}
+		if p.Multiplier < 0 {
+			return fmt.Errorf("invalid workload: rate_curve multipliers must be positive, got [%g]", p.Multiplier)
+		}
+	}
+
+	return nil
+}
+
+func (w *Workload) RateAt(t time.Time) float64 {
+	return w.CapRate(w.GetTargetRequestsPerSecond() * w.curveMultiplierAt(t))
+}
+
+func (w *Workload) CapRate(rate float64) float64 {
+	if w.MaxRequestsPerSecond > 0 {
+		rate = math.Min(rate, float64(w.MaxRequestsPerSecond))
+	}
+
+	return rate
+}
+
+// curveMultiplierAt interpolates the rate curve at the UTC hour of day.
+func (w *Workload) curveMultiplierAt(t time.Time) float64 {
+	if len(w.RateCurve) == 0 {
+		return 1
+	}
+
+	points := make([]RateCurvePoint, len(w.RateCurve))
+	copy(points, w.RateCurve)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Hour < points[j].Hour
+	})
+
+	t = t.UTC()
+	hour := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+
+	prev := points[len(points)-1]
+	prev.Hour -= 24
+	next := points[0]
+	next.Hour += 24
+	for _, p := range points {
+		if p.Hour <= hour {
+			prev = p
+		}
+	}
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].Hour > hour {
+			next = points[i]
+		}
+	}
+
+	if next.Hour == prev.Hour {
+		return prev.Multiplier
+	}
+
+	fraction := (hour - prev.Hour) / (next.Hour - prev.Hour)
+	return prev.Multiplier + fraction*(next.Multiplier-prev.Multiplier)
+}
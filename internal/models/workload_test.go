@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestWorkloadValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		workload Workload
+		valid    bool
+	}{
+		{name: "defaults", valid: true},
+		{
+			name: "full",
+			workload: Workload{
+				MinIntervalSeconds:      10,
+				MaxIntervalSeconds:      60,
+				TargetRequestsPerSecond: 20,
+				Jitter:                  0.5,
+				Workers:                 4,
+				RateCurve:               []RateCurvePoint{{Hour: 0, Multiplier: 0}, {Hour: 23.5, Multiplier: 2}},
+			},
+			valid: true,
+		},
+		{name: "negative workers", workload: Workload{Workers: -1}},
+		{name: "negative jitter", workload: Workload{Jitter: -0.1}},
+		{name: "jitter above 1", workload: Workload{Jitter: 1.1}},
+		{name: "negative target rate", workload: Workload{TargetRequestsPerSecond: -1}},
+		{name: "negative min interval", workload: Workload{MinIntervalSeconds: -1}},
+		{name: "min interval above max", workload: Workload{MinIntervalSeconds: 60, MaxIntervalSeconds: 10}},
+		{name: "negative rate curve hour", workload: Workload{RateCurve: []RateCurvePoint{{Hour: -1, Multiplier: 1}}}},
+		{name: "rate curve hour 24", workload: Workload{RateCurve: []RateCurvePoint{{Hour: 24, Multiplier: 1}}}},
+		{name: "negative rate curve multiplier", workload: Workload{RateCurve: []RateCurvePoint{{Hour: 12, Multiplier: -1}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.workload.Validate()
+			if test.valid && err != nil {
+				t.Fatalf("expected workload to be valid, got [%v]", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatal("expected workload to be invalid")
+			}
+		})
+	}
+}
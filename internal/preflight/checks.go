@@ -0,0 +1,195 @@
+package preflight
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/deployment"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+var (
+	usageIndices = []string{"aggregations-*", "usage-v*"}
+
+	stateIndices = []string{"gds-*"}
+)
+
+const validationDeploymentName = "ecbgd-preflight"
+
+func Checks(cfg *config.Config, store *dao.Store) []Check {
+	checks := []Check{
+		{
+			Name: "usage cluster",
+			Run: func() (string, error) {
+				return checkCluster(cfg.UsageCluster, usageIndices, []string{"read"})
+			},
+		},
+	}
+
+	if cfg.GetStoreType() == config.StoreTypeElasticsearch || cfg.Auth.ElasticsearchAPIKeys {
+		checks = append(checks, Check{
+			Name: "state cluster",
+			Run: func() (string, error) {
+				return checkCluster(cfg.StateCluster, stateIndices, []string{"read", "write"})
+			},
+		})
+	}
+
+	if cfg.GetStoreType() == config.StoreTypeLocal {
+		checks = append(checks, Check{
+			Name: "local store",
+			Run: func() (string, error) {
+				return checkLocalStore(cfg.GetStorePath())
+			},
+		})
+	}
+
+	checks = append(checks, Check{
+		Name: "Elastic Cloud API",
+		Run: func() (string, error) {
+			return checkCloudAPI(cfg, store.DeploymentConfigurations)
+		},
+	})
+
+	return checks
+}
+
+func checkCluster(cluster config.ElasticsearchCluster, indices, privileges []string) (string, error) {
+	conn, err := es.NewClient(es.Config{
+		Addresses: []string{cluster.Url},
+		Username:  cluster.Username,
+		Password:  cluster.Password,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to create connection to [%s]: %w", cluster.Url, err)
+	}
+
+	res, err := conn.Security.Authenticate()
+	if err != nil {
+		return "", fmt.Errorf("unable to reach [%s]: %w", cluster.Url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("invalid credentials for user [%s] at [%s]", cluster.Username, cluster.Url)
+	}
+	if res.IsError() {
+		return "", fmt.Errorf("unable to authenticate at [%s]: %w", cluster.Url, esutil.ResponseError(res))
+	}
+
+	missing, err := missingPrivileges(conn, indices, privileges)
+	if err != nil {
+		return "", err
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("user [%s] at [%s] lacks privileges [%s]", cluster.Username, cluster.Url, strings.Join(missing, ", "))
+	}
+
+	return fmt.Sprintf("user [%s] at [%s] has privileges [%s] on [%s]",
+		cluster.Username, cluster.Url, strings.Join(privileges, ", "), strings.Join(indices, ", ")), nil
+}
+
+func missingPrivileges(conn *es.Client, indices, privileges []string) ([]string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"index": []map[string]interface{}{
+			{
+				"names":      indices,
+				"privileges": privileges,
+			},
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("unable to encode privileges as JSON: %w", err)
+	}
+
+	res, err := conn.Security.HasPrivileges(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check privileges: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("unable to check privileges: %w", esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Index map[string]map[string]bool `json:"index"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	var missing []string
+	for index, granted := range r.Index {
+		for privilege, ok := range granted {
+			if !ok {
+				missing = append(missing, fmt.Sprintf("%s on %s", privilege, index))
+			}
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+func checkLocalStore(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("unable to create local store directory [%s]: %w", dir, err)
+	}
+
+	f, err := ioutil.TempFile(dir, ".preflight-")
+	if err != nil {
+		return "", fmt.Errorf("unable to write to local store directory [%s]: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return fmt.Sprintf("directory [%s] is writable", dir), nil
+}
+
+// Whether the API key can create deployments is checked by validating, without
+// creating, a deployment from the first deployment configuration.
+func checkCloudAPI(cfg *config.Config, deploymentConfigs dao.DeploymentConfigurationRepository) (string, error) {
+	essConn, err := deployment.NewAPI(cfg.API.Url, cfg.API.Key)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := deploymentapi.List(deploymentapi.ListParams{API: essConn}); err != nil {
+		return "", fmt.Errorf("unable to list deployments at [%s]: %w", cfg.API.Url, err)
+	}
+
+	configs, err := deploymentConfigs.ListAll()
+	if err != nil {
+		return "", fmt.Errorf("API key can list deployments at [%s], but creating deployments was not checked, as deployment configurations could not be read: %w",
+			cfg.API.Url, ErrSkipped)
+	}
+	if len(configs) == 0 {
+		return "", fmt.Errorf("API key can list deployments at [%s], but creating deployments was not checked, as there are no deployment configurations: %w",
+			cfg.API.Url, ErrSkipped)
+	}
+
+	deploymentConfig := configs[0]
+	req, err := deploymentConfig.ToDeploymentCreateRequest(nil)
+	if err != nil {
+		return "", fmt.Errorf("API key can list deployments at [%s], but creating deployments was not checked, as deployment configuration [%s] is invalid: %w",
+			cfg.API.Url, deploymentConfig.ID, ErrSkipped)
+	}
+
+	if err := deployment.ValidateDeployment(essConn, validationDeploymentName, req); err != nil {
+		return "", fmt.Errorf("API key cannot create deployments from configuration [%s] at [%s]: %w", deploymentConfig.ID, cfg.API.Url, err)
+	}
+
+	return fmt.Sprintf("API key can create deployments from configuration [%s] at [%s]", deploymentConfig.ID, cfg.API.Url), nil
+}
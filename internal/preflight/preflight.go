@@ -0,0 +1,77 @@
+// Package preflight checks the service's external dependencies.
+package preflight
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrSkipped is returned by checks that could not be run. It does not fail the
+// preflight.
+var ErrSkipped = errors.New("skipped")
+
+type Check struct {
+	Name string
+	Run  func() (string, error)
+}
+
+type Result struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+func (r Result) status() string {
+	switch {
+	case r.Err == nil:
+		return "OK"
+	case errors.Is(r.Err, ErrSkipped):
+		return "SKIP"
+	default:
+		return "FAIL"
+	}
+}
+
+type Report []Result
+
+func Run(checks []Check) Report {
+	report := make(Report, 0, len(checks))
+	for _, check := range checks {
+		detail, err := check.Run()
+		report = append(report, Result{
+			Name:   check.Name,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+
+	return report
+}
+
+func (r Report) Print(w io.Writer) {
+	for _, result := range r {
+		line := result.Detail
+		if result.Err != nil {
+			line = result.Err.Error()
+		}
+
+		fmt.Fprintf(w, "[%-4s] %s: %s\n", result.status(), result.Name, line)
+	}
+}
+
+func (r Report) Err() error {
+	var failed []string
+	for _, result := range r {
+		if result.status() == "FAIL" {
+			failed = append(failed, result.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("preflight checks failed: [%s]", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
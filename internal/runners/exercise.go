@@ -0,0 +1,225 @@
+package runners
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/metrics"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+)
+
+type OpType string
+
+const (
+	OpSearch OpType = "search"
+	OpIndex         = "index"
+)
+
+const rateUpdateInterval = 1 * time.Second
+
+func (rs *runningScenario) runExerciseLoop(ctx context.Context) error {
+	loggingParam := zap.String("scenario", rs.ID)
+	logging.Logger.Info("starting exercise loop", loggingParam, zap.Int("workers", rs.Workload.GetWorkers()))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startTime := rs.GetExerciseStartTime()
+
+	limiter := newRateLimiter(0, rs.Workload.GetBurst())
+
+	var wg sync.WaitGroup
+	crashes := make(chan error, rs.Workload.GetWorkers())
+	for i := 0; i < rs.Workload.GetWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run := func(ctx context.Context) error {
+				return rs.runWorker(ctx, limiter)
+			}
+			if err := runRecovered(ctx, run); err != nil {
+				crashes <- err
+				cancel()
+			}
+		}()
+	}
+
+	rs.controlRate(ctx, limiter, startTime)
+	wg.Wait()
+
+	select {
+	case err := <-crashes:
+		return fmt.Errorf("exercise worker crashed: %w", err)
+	default:
+		return nil
+	}
+}
+
+// controlRate sets the rate limiter's rate to the workload's rate, or to zero
+// between bursts.
+func (rs *runningScenario) controlRate(ctx context.Context, limiter *rateLimiter, startTime time.Time) {
+	loggingParam := zap.String("scenario", rs.ID)
+
+	ticker := time.NewTicker(rateUpdateInterval)
+	defer ticker.Stop()
+
+	var burstEnd, idleEnd time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Logger.Info("exercise loop done for scenario", loggingParam)
+			return
+
+		case t := <-ticker.C:
+			if t.Before(startTime) {
+				logging.Logger.Debug("not yet time to start exercising scenario", loggingParam)
+				continue
+			}
+
+			if rs.Workload.IsCycling() && !t.Before(burstEnd) {
+				if t.Before(idleEnd) {
+					limiter.SetRate(0)
+					continue
+				}
+
+				idleInterval := rs.Workload.NextIdleInterval()
+				burstEnd = t.Add(rs.Workload.GetBurstDuration())
+				idleEnd = burstEnd.Add(idleInterval)
+				logging.Logger.Debug("starting burst", loggingParam,
+					zap.Time("burst_end", burstEnd),
+					zap.Duration("idle_interval", idleInterval),
+				)
+			}
+
+			rate := rs.Workload.CapRate(applyJitter(rs.Workload.RateAt(t), rs.Workload.Jitter))
+			logging.Logger.Debug("setting request rate", loggingParam, zap.Float64("rate", rate))
+			limiter.SetRate(rate)
+		}
+	}
+}
+
+func (rs *runningScenario) runWorker(ctx context.Context, limiter *rateLimiter) error {
+	loggingParam := zap.String("scenario", rs.ID)
+	target := rs.GetWorkloadTarget()
+
+	for {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil
+		}
+
+		var err error
+		op := randOp(rs.Workload.IndexToSearchRatio)
+		start := time.Now()
+		switch op {
+		case OpSearch:
+			logging.Logger.Debug("firing search request", loggingParam)
+			err = doSearch(ctx, rs.goldenConn, target+"*")
+
+		case OpIndex:
+			logging.Logger.Debug("firing index request", loggingParam)
+			err = doIndex(ctx, rs.goldenConn, target, randIndexBody())
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		metrics.ObserveExerciseOperation(rs.ID, string(op), time.Since(start), err)
+
+		if err != nil {
+			logging.Logger.Error(err.Error(), loggingParam)
+		}
+	}
+}
+
+func applyJitter(rate, jitter float64) float64 {
+	jitter = math.Max(0, math.Min(1, jitter))
+	return rate * (1 + jitter*(2*rand.Float64()-1))
+}
+
+func randOp(indexToSearchRatio int) OpType {
+	ops := make([]OpType, 1+indexToSearchRatio)
+	ops[0] = OpSearch
+	for i := 1; i < len(ops); i++ {
+		ops[i] = OpIndex
+	}
+
+	randIdx := rand.Intn(len(ops))
+	return ops[randIdx]
+}
+
+func randIndexBody() json.RawMessage {
+	messages := []string{
+		"the quick brown fox",
+		"jumped over the",
+		"lazy dog",
+	}
+
+	innerKeys := []string{"count", "sum"}
+
+	randMsgIdx := rand.Intn(len(messages))
+	randMsg := messages[randMsgIdx]
+
+	randKeyIdx := rand.Intn(len(innerKeys))
+	randKey := innerKeys[randKeyIdx]
+
+	randNum := (17 + rand.Intn(10000)) % 523
+
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+
+	bodyTpl := `{"@timestamp":"%s","message":"%s","metric":{"%s":%d}}`
+	body := fmt.Sprintf(bodyTpl, timestamp, randMsg, randKey, randNum)
+
+	return json.RawMessage(body)
+}
+
+func doSearch(ctx context.Context, esClient *es.Client, target string) error {
+	res, err := esClient.Search(
+		esClient.Search.WithContext(ctx),
+		esClient.Search.WithIndex(target),
+	)
+	if err != nil {
+		return fmt.Errorf("search operation failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("search operation failed: %w", esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func doIndex(ctx context.Context, esClient *es.Client, target string, body json.RawMessage) error {
+	var b bytes.Buffer
+	if len(body) > 0 {
+		b.Write(body)
+	}
+
+	res, err := esClient.Index(
+		target,
+		&b,
+		esClient.Index.WithContext(ctx),
+		// Data streams only accept documents that are created, not indexed
+		esClient.Index.WithOpType("create"),
+	)
+	if err != nil {
+		return fmt.Errorf("index operation failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("index operation failed: %w", esutil.ResponseError(res))
+	}
+
+	return nil
+}
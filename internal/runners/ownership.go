@@ -0,0 +1,257 @@
+package runners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+var ErrScenarioOwnedElsewhere = errors.New("scenario is owned by another replica")
+
+// Run resumes the scenarios that should be running and, when leases are
+// enabled, keeps reconciling them until the context is done.
+func (sr *ScenarioRunner) Run(ctx context.Context) error {
+	if err := sr.reconcile(); err != nil {
+		return err
+	}
+
+	sr.mu.Lock()
+	sr.initialized = true
+	sr.mu.Unlock()
+
+	if !sr.cfg.Leases.Enabled {
+		return nil
+	}
+
+	logging.Logger.Info("reconciling scenario leases",
+		zap.String("owner", sr.owner),
+		zap.Duration("lease_duration", sr.cfg.GetLeaseDuration()),
+	)
+
+	go func() {
+		ticker := time.NewTicker(sr.renewInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := sr.reconcile(); err != nil {
+					logging.Logger.Error("unable to reconcile scenarios", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (sr *ScenarioRunner) reconcile() error {
+	scenarioDAO := sr.store.Scenarios
+	scenarios, err := scenarioDAO.ListAll()
+	if err != nil {
+		if sr.cfg.Leases.Enabled {
+			sr.stopExpiringScenarios()
+		}
+		return fmt.Errorf("could not load all scenarios: %w", err)
+	}
+
+	for i := range scenarios {
+		scenario := &scenarios[i]
+		loggingParam := zap.String("scenario", scenario.ID)
+
+		if _, running := sr.get(scenario.ID); running {
+			// Scenarios may be changed through another replica's API
+			if sr.applyChangesElsewhere(scenario) {
+				continue
+			}
+
+			if sr.cfg.Leases.Enabled {
+				sr.renewLease(scenario.ID)
+			}
+			continue
+		}
+
+		if !scenario.IsResumable() {
+			logging.Logger.Debug("not resuming scenario",
+				loggingParam,
+				zap.String("status", string(scenario.GetState())),
+			)
+			continue
+		}
+
+		if err := sr.Start(scenario); err != nil {
+			if errors.Is(err, ErrScenarioOwnedElsewhere) {
+				logging.Logger.Debug("scenario is owned by another replica", loggingParam)
+				continue
+			}
+			logging.Logger.Error("unable to resume scenario", loggingParam, zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// applyChangesElsewhere pauses, resumes or stops the running scenario if it
+// was changed through another replica's API. It returns whether it stopped it.
+func (sr *ScenarioRunner) applyChangesElsewhere(persisted *models.Scenario) bool {
+	rs, running := sr.lock(persisted.ID)
+	if !running {
+		return true
+	}
+
+	from := rs.GetState()
+	validationsPaused := rs.AreValidationsPaused()
+	if !rs.adopt(persisted) || rs.GetState() == from {
+		rs.mu.Unlock()
+		return false
+	}
+
+	to := rs.GetState()
+	logging.Logger.Info("scenario was changed elsewhere, applying change here",
+		zap.String("scenario", persisted.ID),
+		zap.String("from", string(from)),
+		zap.String("to", string(to)),
+	)
+
+	stop := false
+	switch to {
+	case models.ScenarioStatePaused:
+		if from == models.ScenarioStateExercising {
+			rs.exerciseCancelFunc()
+			if rs.AreValidationsPaused() {
+				rs.validationCancelFunc()
+			}
+		}
+	case models.ScenarioStateExercising:
+		if from == models.ScenarioStatePaused {
+			rs.startExerciseLoop()
+			if validationsPaused {
+				rs.startValidationLoop()
+			}
+			sr.applyWatch(persisted.ID)
+		}
+	case models.ScenarioStateStopping, models.ScenarioStateStopped, models.ScenarioStateFailed:
+		stop = true
+	}
+	rs.mu.Unlock()
+
+	if stop {
+		_ = sr.Stop(persisted.ID)
+	}
+	return stop
+}
+
+// The caller must hold rs.mu.
+func (rs *runningScenario) adopt(persisted *models.Scenario) bool {
+	if !persisted.Version.IsNewerThan(rs.Version) {
+		return false
+	}
+
+	rs.Status = persisted.Status
+	rs.Transitions = persisted.Transitions
+	rs.PausedIntervals = persisted.PausedIntervals
+	rs.StoppedOn = persisted.StoppedOn
+	rs.Version = persisted.Version
+	return true
+}
+
+func (sr *ScenarioRunner) acquireLease(scenarioID string) (bool, error) {
+	if !sr.cfg.Leases.Enabled {
+		return true, nil
+	}
+
+	leaseDAO := sr.store.Leases
+	return leaseDAO.TryAcquire(scenarioID, sr.owner, sr.cfg.GetLeaseDuration())
+}
+
+// renewLease stops the scenario if its lease was lost, or if it could not be
+// renewed before it expires.
+func (sr *ScenarioRunner) renewLease(scenarioID string) {
+	loggingParam := zap.String("scenario", scenarioID)
+
+	renewedOn := time.Now()
+	renewed, err := sr.acquireLease(scenarioID)
+	if err != nil {
+		logging.Logger.Error("unable to renew scenario lease", loggingParam, zap.Error(err))
+		sr.stopIfLeaseExpiring(scenarioID)
+		return
+	}
+	if !renewed {
+		logging.Logger.Warn("lost scenario lease, stopping scenario", loggingParam)
+		_ = sr.Stop(scenarioID)
+		return
+	}
+
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		return
+	}
+	rs.leaseExpiresOn = renewedOn.Add(sr.cfg.GetLeaseDuration())
+	rs.mu.Unlock()
+}
+
+func (sr *ScenarioRunner) stopExpiringScenarios() {
+	sr.mu.RLock()
+	scenarioIDs := make([]string, 0, len(sr.scenarios))
+	for id := range sr.scenarios {
+		scenarioIDs = append(scenarioIDs, id)
+	}
+	sr.mu.RUnlock()
+
+	for _, id := range scenarioIDs {
+		sr.stopIfLeaseExpiring(id)
+	}
+}
+
+func (sr *ScenarioRunner) stopIfLeaseExpiring(scenarioID string) {
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		return
+	}
+	expiresOn := rs.leaseExpiresOn
+	rs.mu.Unlock()
+
+	if time.Now().Add(sr.renewInterval()).Before(expiresOn) {
+		return
+	}
+
+	logging.Logger.Warn("scenario lease expires without being renewed, stopping scenario",
+		zap.String("scenario", scenarioID),
+		zap.Time("expires_on", expiresOn),
+	)
+	_ = sr.Stop(scenarioID)
+}
+
+func (sr *ScenarioRunner) renewInterval() time.Duration {
+	return sr.cfg.GetLeaseDuration() / 3
+}
+
+func (sr *ScenarioRunner) releaseLease(scenarioID string) {
+	if !sr.cfg.Leases.Enabled {
+		return
+	}
+
+	leaseDAO := sr.store.Leases
+	if err := leaseDAO.Release(scenarioID, sr.owner); err != nil {
+		logging.Logger.Error("unable to release scenario lease", zap.String("scenario", scenarioID), zap.Error(err))
+	}
+}
+
+func newOwnerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%s", hostname, uuid.New().String())
+}
@@ -0,0 +1,138 @@
+package runners
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+// Pause stops exercising, and optionally validating, the scenario. A scenario
+// running in another replica is only persisted as paused.
+func (sr *ScenarioRunner) Pause(scenarioID, reason string, pauseValidations bool, expected *models.Version) (*models.Scenario, error) {
+	scenarioDAO := sr.store.Scenarios
+
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		s, err := scenarioDAO.Get(scenarioID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkVersion(s, expected); err != nil {
+			return nil, err
+		}
+		if err := s.TransitionTo(models.ScenarioStatePaused, reason); err != nil {
+			return nil, err
+		}
+		s.Pause(reason, pauseValidations)
+
+		if err := scenarioDAO.Save(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	defer rs.mu.Unlock()
+
+	if err := checkVersion(rs.Scenario, expected); err != nil {
+		return nil, err
+	}
+
+	s := rs.snapshot()
+	if err := s.TransitionTo(models.ScenarioStatePaused, reason); err != nil {
+		return nil, err
+	}
+	s.Pause(reason, pauseValidations)
+	if err := scenarioDAO.Save(s); err != nil {
+		return nil, err
+	}
+	rs.adopt(s)
+
+	logging.Logger.Info("pausing scenario",
+		zap.String("scenario", scenarioID),
+		zap.Bool("validations", pauseValidations),
+	)
+	rs.exerciseCancelFunc()
+	if pauseValidations {
+		rs.validationCancelFunc()
+	}
+
+	return rs.snapshot(), nil
+}
+
+// Resume starts exercising the paused scenario again. A scenario running in
+// another replica is only persisted as exercising.
+func (sr *ScenarioRunner) Resume(scenarioID, reason string, expected *models.Version) (*models.Scenario, error) {
+	scenarioDAO := sr.store.Scenarios
+
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		s, err := scenarioDAO.Get(scenarioID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkVersion(s, expected); err != nil {
+			return nil, err
+		}
+		if s.GetState() != models.ScenarioStatePaused {
+			return nil, &models.InvalidTransitionError{
+				ScenarioID: s.ID,
+				From:       s.GetState(),
+				To:         models.ScenarioStateExercising,
+			}
+		}
+
+		s.Unpause()
+		err = sr.Start(s)
+		if errors.Is(err, ErrScenarioOwnedElsewhere) {
+			if err := s.TransitionTo(models.ScenarioStateExercising, reason); err != nil {
+				return nil, err
+			}
+			if err := scenarioDAO.Save(s); err != nil {
+				return nil, err
+			}
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rs, running := sr.lock(scenarioID)
+		if !running {
+			return nil, fmt.Errorf("unable to resume scenario [%s]: %w", scenarioID, ErrScenarioNotRunning)
+		}
+		defer rs.mu.Unlock()
+
+		return rs.snapshot(), nil
+	}
+	defer rs.mu.Unlock()
+
+	if err := checkVersion(rs.Scenario, expected); err != nil {
+		return nil, err
+	}
+
+	s := rs.snapshot()
+	if err := s.TransitionTo(models.ScenarioStateExercising, reason); err != nil {
+		return nil, err
+	}
+	validationsPaused := s.AreValidationsPaused()
+	s.Unpause()
+	if err := scenarioDAO.Save(s); err != nil {
+		return nil, err
+	}
+	rs.adopt(s)
+
+	logging.Logger.Info("resuming scenario", zap.String("scenario", scenarioID))
+
+	rs.startExerciseLoop()
+	if validationsPaused {
+		rs.startValidationLoop()
+	}
+	sr.applyWatch(scenarioID)
+
+	return rs.snapshot(), nil
+}
@@ -0,0 +1,90 @@
+package runners
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const idleRatePollInterval = 1 * time.Second
+
+// rateLimiter is a token bucket rate limiter whose rate may be changed while
+// requests are waiting on it.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens in the bucket
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	rl := new(rateLimiter)
+	rl.rate = rate
+	rl.burst = math.Max(1, float64(burst))
+	rl.last = time.Now()
+
+	return rl
+}
+
+func (rl *rateLimiter) SetRate(rate float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	rl.rate = math.Max(0, rate)
+}
+
+func (rl *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := rl.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (rl *rateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+
+	if rl.rate <= 0 {
+		return idleRatePollInterval
+	}
+
+	delay := time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+	if delay > idleRatePollInterval {
+		delay = idleRatePollInterval
+	}
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+
+	return delay
+}
+
+func (rl *rateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(rl.last).Seconds()
+	rl.last = now
+	if elapsed <= 0 {
+		return
+	}
+
+	rl.tokens = math.Min(rl.burst, rl.tokens+elapsed*rl.rate)
+}
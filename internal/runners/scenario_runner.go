@@ -1,12 +1,10 @@
 package runners
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
-	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,100 +13,225 @@ import (
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/deployment"
 
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/alerting"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/metrics"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/stack"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/usage"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/watches"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
-	"github.com/elastic/cloud-sdk-go/pkg/auth"
 
 	es "github.com/elastic/go-elasticsearch/v7"
 )
 
-type OpType string
-
-const (
-	OpSearch OpType = "search"
-	OpIndex         = "index"
+var (
+	ErrScenarioNotRunning     = errors.New("scenario is not running")
+	ErrScenarioAlreadyRunning = errors.New("scenario is already running")
+	ErrScenarioChanged        = errors.New("scenario was changed")
 )
 
 type runningScenario struct {
+	// mu guards the scenario and the cancel funcs.
+	mu sync.Mutex
 	*models.Scenario
 
+	ctx                  context.Context
+	cancelFunc           context.CancelFunc
 	exerciseCancelFunc   context.CancelFunc
 	validationCancelFunc context.CancelFunc
+	supervisor           *supervisor
+
+	leaseExpiresOn time.Time
 
 	usageConn  *usage.Connection
-	stateConn  *es.Client
+	store      *dao.Store
+	alerter    *alerting.Alerter
 	goldenConn *es.Client
 }
 
 type ScenarioRunner struct {
-	cfg       *config.Config
-	scenarios map[string]runningScenario
+	cfg *config.Config
+
+	owner string
+
+	mu        sync.RWMutex
+	scenarios map[string]*runningScenario
+
+	// starting holds the scenarios being started, and whether they were stopped
+	// meanwhile.
+	starting map[string]bool
+
+	initialized bool
+
+	// loops tracks the scenario loops and the scenarios being provisioned.
+	loops sync.WaitGroup
 
 	usageConn *usage.Connection
-	stateConn *es.Client
+	store     *dao.Store
 	essConn   *api.API
+	alerter   *alerting.Alerter
+
+	watches *watches.Installer
 }
 
-func NewScenarioRunner(cfg *config.Config) (*ScenarioRunner, error) {
+func NewScenarioRunner(cfg *config.Config, store *dao.Store) (*ScenarioRunner, error) {
 	sr := new(ScenarioRunner)
 	sr.cfg = cfg
-	sr.scenarios = map[string]runningScenario{}
+	sr.owner = newOwnerID()
+	sr.scenarios = map[string]*runningScenario{}
+	sr.starting = map[string]bool{}
 
 	usageConn, err := sr.initUsageClusterConnection()
 	if err != nil {
 		return nil, err
 	}
 
-	stateConn, err := es.NewClient(es.Config{
-		Addresses: []string{cfg.StateCluster.Url},
-		Username:  cfg.StateCluster.Username,
-		Password:  cfg.StateCluster.Password,
-	})
+	essConn, err := deployment.NewAPI(cfg.API.Url, cfg.API.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	essConn, err := api.NewAPI(api.Config{
-		Host:       cfg.API.Url,
-		Client:     new(http.Client),
-		AuthWriter: auth.APIKey(cfg.API.Key),
-	})
+	alerter, err := alerting.NewAlerter(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("unable to connect to Elastic Cloud API at [%s]: %w", cfg.API.Url, err)
+		return nil, err
 	}
 
 	sr.usageConn = usageConn
-	sr.stateConn = stateConn
+	sr.store = store
 	sr.essConn = essConn
+	sr.alerter = alerter
+
+	if cfg.Watches.ValidationFailures.PerScenario {
+		stateConn, err := dao.NewStateClusterConnection(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sr.watches = watches.NewInstaller(stateConn, cfg.Watches.ValidationFailures)
+	}
 
 	return sr, nil
 }
 
+func (sr *ScenarioRunner) Ready() error {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	if !sr.initialized {
+		return errors.New("scenario runner is not initialized")
+	}
+
+	return nil
+}
+
+func (sr *ScenarioRunner) PingUsageCluster() error {
+	return sr.usageConn.Ping()
+}
+
+func (sr *ScenarioRunner) Validate(s *models.Scenario) error {
+	if err := s.Workload.Validate(); err != nil {
+		return err
+	}
+
+	for _, asset := range s.Setup.Assets {
+		if err := asset.Validate(); err != nil {
+			return err
+		}
+
+		if _, err := asset.Contents(sr.cfg.Setup.AssetsDir); err != nil {
+			return err
+		}
+	}
+
+	return sr.alerter.Validate(s.Alerts)
+}
+
 func (sr *ScenarioRunner) Start(s *models.Scenario) error {
-	logging.Logger.Info("starting scenario runner...")
+	logging.Logger.Info("starting scenario runner...", zap.String("scenario", s.ID))
+
+	if !sr.reserve(s.ID) {
+		return fmt.Errorf("unable to start scenario [%s]: %w", s.ID, ErrScenarioAlreadyRunning)
+	}
+
+	acquiredOn := time.Now()
+	acquired, err := sr.acquireLease(s.ID)
+	if err != nil {
+		sr.unreserve(s.ID)
+		return fmt.Errorf("unable to start scenario [%s]: %w", s.ID, err)
+	}
+	if !acquired {
+		sr.unreserve(s.ID)
+		return fmt.Errorf("unable to start scenario [%s]: %w", s.ID, ErrScenarioOwnedElsewhere)
+	}
+
+	// Saving fails if the scenario was changed while it was prepared.
+	if err := sr.prepare(s); err != nil {
+		sr.unreserve(s.ID)
+		sr.releaseLease(s.ID)
+		return err
+	}
+
+	rs := &runningScenario{
+		Scenario:       s,
+		leaseExpiresOn: acquiredOn.Add(sr.cfg.GetLeaseDuration()),
+		usageConn:      sr.usageConn,
+		store:          sr.store,
+		alerter:        sr.alerter,
+	}
+	rs.ctx, rs.cancelFunc = context.WithCancel(context.Background())
+	rs.supervisor = newSupervisor(rs.ctx, s.ID, &sr.loops)
 
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if !sr.register(rs) {
+		rs.cancelFunc()
+		sr.releaseLease(s.ID)
+		return fmt.Errorf("unable to start scenario [%s]: stopped while starting", s.ID)
+	}
+
+	reason := "provisioning golden deployment"
+	if s.GetState() != models.ScenarioStatePending {
+		reason = "resuming scenario"
+	}
+	err = s.TransitionTo(models.ScenarioStateProvisioning, reason)
+	if err == nil {
+		scenarioDAO := sr.store.Scenarios
+		err = scenarioDAO.Save(s)
+	}
+	if err != nil {
+		rs.cancelFunc()
+		sr.unregister(s.ID)
+		sr.releaseLease(s.ID)
+		return err
+	}
+
+	sr.loops.Add(1)
+	go sr.provision(rs)
+
+	return nil
+}
+
+func (sr *ScenarioRunner) prepare(s *models.Scenario) error {
 	deploymentName := s.GetDeploymentName()
-	exists, err := deployment.CheckIfDeploymentExists(sr.essConn, deploymentName)
+	deploymentID, err := deployment.GetDeploymentID(sr.essConn, deploymentName)
 	if err != nil {
 		return fmt.Errorf("unable to check if deployment [%s] exists: %w", deploymentName, err)
 	}
 
-	if !exists {
+	if deploymentID == "" {
 		// Create deployment
-		deploymentConfigDAO := dao.NewDeploymentConfiguration(sr.stateConn)
+		deploymentConfigDAO := sr.store.DeploymentConfigurations
 		deploymentConfig, err := deploymentConfigDAO.Get(s.DeploymentConfiguration.ID)
+		if errors.Is(err, dao.ErrNotFound) {
+			return fmt.Errorf("deployment configuration [%s] specified in scenario [%s] does not exist", s.DeploymentConfiguration.ID, s.ID)
+		}
 		if err != nil {
 			return err
 		}
 
-		if deploymentConfig == nil {
-			return fmt.Errorf("deployment configuration [%s] specified in scenario [%s] does not exist", s.DeploymentConfiguration.ID, s.ID)
-		}
-
 		req, err := deploymentConfig.ToDeploymentCreateRequest(s.DeploymentConfiguration.Variables)
 		if err != nil {
 			return fmt.Errorf("unable to create deployment create request from configuration [%s]: %w", deploymentConfig.ID, err)
@@ -123,152 +246,444 @@ func (sr *ScenarioRunner) Start(s *models.Scenario) error {
 			return err
 		}
 
+		deploymentID = out.DeploymentID
 		s.ClusterIDs = out.ClusterIDs
 		s.DeploymentCredentials = out.DeploymentCredentials
 	}
+	s.DeploymentID = deploymentID
+
+	if s.StartedOn == nil {
+		now := time.Now()
+		s.StartedOn = &now
+	}
+	if s.ExerciseStartedOn == nil {
+		exerciseStartedOn := s.GetExerciseStartTime()
+		s.ExerciseStartedOn = &exerciseStartedOn
+	}
+
+	return nil
+}
+
+func (sr *ScenarioRunner) provision(rs *runningScenario) {
+	defer sr.loops.Done()
+
+	loggingParam := zap.String("scenario", rs.ID)
+	scenarioDAO := sr.store.Scenarios
+
+	fail := func(err error) {
+		logging.Logger.Error("unable to provision golden deployment", loggingParam, zap.Error(err))
+
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+
+		if err := rs.TransitionTo(models.ScenarioStateFailed, err.Error()); err != nil {
+			logging.Logger.Error("unable to mark scenario as failed", loggingParam, zap.Error(err))
+			return
+		}
+		if err := scenarioDAO.Save(rs.Scenario); err != nil {
+			logging.Logger.Error("unable to save scenario", loggingParam, zap.Error(err))
+		}
+
+		rs.cancelFunc()
+		sr.unregister(rs.ID)
+		sr.releaseLease(rs.ID)
+	}
+
+	logging.Logger.Info("waiting for golden deployment to become healthy...",
+		loggingParam,
+		zap.String("deployment", rs.DeploymentID),
+		zap.Duration("timeout", sr.cfg.GetDeploymentHealthTimeout()),
+	)
+	if err := deployment.WaitForHealthy(
+		rs.ctx, sr.essConn, rs.DeploymentID,
+		sr.cfg.GetDeploymentHealthTimeout(), sr.cfg.GetDeploymentHealthPollInterval(),
+	); err != nil {
+		if rs.ctx.Err() != nil {
+			// Scenario was stopped while provisioning
+			return
+		}
+		fail(err)
+		return
+	}
 
-	goldenConn, err := es.NewClient(es.Config{
-		CloudID:  s.DeploymentCredentials.CloudID,
-		Username: s.DeploymentCredentials.Username,
-		Password: s.DeploymentCredentials.Password,
-	})
+	goldenConn, err := deployment.NewClient(rs.DeploymentCredentials)
 	if err != nil {
-		return fmt.Errorf("unable to create connection to golden deployment: %w", err)
+		fail(fmt.Errorf("unable to create connection to golden deployment: %w", err))
+		return
 	}
 
-	exerciseCtx, exerciseCancelFunc := context.WithCancel(context.Background())
-	validationCtx, validationCancelFunc := context.WithCancel(context.Background())
+	// Set up a copy of the scenario without holding the lock, so that it can be
+	// stopped meanwhile.
+	rs.mu.Lock()
+	s := rs.snapshot()
+	rs.mu.Unlock()
+
+	if scopedConn, err := scopeCredentials(goldenConn, s); err != nil {
+		logging.Logger.Warn("unable to create scoped API key in golden deployment, keeping superuser credentials",
+			loggingParam, zap.Error(err))
+	} else if scopedConn != nil {
+		goldenConn = scopedConn
+	}
 
-	rs := runningScenario{
-		Scenario:             s,
-		exerciseCancelFunc:   exerciseCancelFunc,
-		validationCancelFunc: validationCancelFunc,
-		usageConn:            sr.usageConn,
-		stateConn:            sr.stateConn,
-		goldenConn:           goldenConn,
+	rs.mu.Lock()
+	if rs.ctx.Err() != nil {
+		rs.mu.Unlock()
+		return
 	}
+	rs.DeploymentCredentials = s.DeploymentCredentials
+	rs.goldenConn = goldenConn
+	rs.mu.Unlock()
 
-	sr.scenarios[s.ID] = rs
+	err = setupWithRetries(rs.ctx, goldenConn, s, sr.cfg.Setup.AssetsDir)
+	if err != nil && rs.ctx.Err() == nil {
+		fail(fmt.Errorf("unable to set up golden deployment: %w", err))
+	}
 
-	if s.StartedOn == nil {
-		scenarioDAO := dao.NewScenario(sr.stateConn)
-		if err := scenarioDAO.Save(s); err != nil {
-			return err
-		}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
 
-		now := time.Now()
-		s.StartedOn = &now
+	if err != nil || rs.ctx.Err() != nil {
+		return
 	}
+	rs.AppliedSetupAssets = s.AppliedSetupAssets
 
-	rs.start(exerciseCtx, validationCtx)
+	sr.applyWatch(rs.ID)
+
+	// Persist the state along with the setup assets that were applied
+	if err := rs.TransitionTo(models.ScenarioStateExercising, "golden deployment is healthy"); err != nil {
+		logging.Logger.Error("unable to start exercising scenario", loggingParam, zap.Error(err))
+		return
+	}
+	if err := scenarioDAO.Save(rs.Scenario); err != nil {
+		logging.Logger.Error("unable to save scenario", loggingParam, zap.Error(err))
+	}
+
+	rs.startExerciseLoop()
+	rs.startValidationLoop()
+}
+
+// Stop stops running the scenario in this replica without changing its
+// persisted state.
+func (sr *ScenarioRunner) Stop(scenarioID string) error {
+	rs, running := sr.unregister(scenarioID)
+	if !running {
+		if sr.stopStarting(scenarioID) {
+			return nil
+		}
+		return fmt.Errorf("unable to stop scenario [%s]: %w", scenarioID, ErrScenarioNotRunning)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.cancelFunc != nil {
+		rs.cancelFunc()
+	}
+	sr.releaseLease(scenarioID)
 
 	return nil
 }
 
-func (sr *ScenarioRunner) Stop(scenarioID string) {
-	rs := sr.scenarios[scenarioID]
+// Terminate stops the scenario for good and persists it as stopped.
+func (sr *ScenarioRunner) Terminate(scenarioID, reason string, expected *models.Version) (*models.Scenario, error) {
+	scenarioDAO := sr.store.Scenarios
 
-	rs.validationCancelFunc()
-	rs.exerciseCancelFunc()
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		s, err := scenarioDAO.Get(scenarioID)
+		if err != nil {
+			return nil, err
+		}
 
-	delete(sr.scenarios, scenarioID)
+		if err := checkVersion(s, expected); err != nil {
+			return nil, err
+		}
+		if err := terminate(scenarioDAO, s, reason, nil); err != nil {
+			return nil, err
+		}
+		sr.deleteWatch(scenarioID)
+
+		return s, nil
+	}
+	defer rs.mu.Unlock()
+
+	if err := checkVersion(rs.Scenario, expected); err != nil {
+		return nil, err
+	}
+
+	stop := func() {
+		sr.unregister(scenarioID)
+
+		if rs.cancelFunc != nil {
+			rs.cancelFunc()
+		}
+		sr.releaseLease(scenarioID)
+	}
+	if err := terminate(scenarioDAO, rs.Scenario, reason, stop); err != nil {
+		return nil, err
+	}
+	sr.deleteWatch(scenarioID)
+
+	return rs.snapshot(), nil
 }
 
-func (sr *ScenarioRunner) StopAll() {
-	for _, scenario := range sr.scenarios {
-		sr.Stop(scenario.ID)
+func checkVersion(s *models.Scenario, expected *models.Version) error {
+	if expected != nil && s.Version != *expected {
+		return fmt.Errorf("unable to change scenario [%s]: %w", s.ID, ErrScenarioChanged)
+	}
+
+	return nil
+}
+
+func (sr *ScenarioRunner) applyWatch(scenarioID string) {
+	if sr.watches == nil {
+		return
+	}
+
+	if err := sr.watches.Apply(scenarioID); err != nil {
+		logging.Logger.Error("unable to install validation failures watch", zap.String("scenario", scenarioID), zap.Error(err))
 	}
 }
 
-func (rs *runningScenario) start(exerciseCtx, validationCtx context.Context) {
-	rs.startExerciseLoop(exerciseCtx)
-	rs.startValidationLoop(validationCtx)
+func (sr *ScenarioRunner) deleteWatch(scenarioID string) {
+	if sr.watches == nil {
+		return
+	}
+
+	if err := sr.watches.Delete(scenarioID); err != nil {
+		logging.Logger.Error("unable to delete validation failures watch", zap.String("scenario", scenarioID), zap.Error(err))
+	}
 }
 
-func (rs *runningScenario) startExerciseLoop(ctx context.Context) {
-	loggingParam := zap.String("scenario", rs.ID)
-	logging.Logger.Info("starting exercise loop", loggingParam)
+func terminate(scenarioDAO dao.ScenarioRepository, s *models.Scenario, reason string, stop func()) error {
+	if err := s.TransitionTo(models.ScenarioStateStopping, reason); err != nil {
+		return err
+	}
+	if err := scenarioDAO.Save(s); err != nil {
+		return err
+	}
+
+	if stop != nil {
+		stop()
+	}
 
-	// TODO: consider start time + offset, if it has already passed, start now!
-	startOffset := time.Duration(rs.Workload.StartOffsetSeconds) * time.Second
 	now := time.Now()
-	startTime := now.Add(startOffset)
+	s.StoppedOn = &now
+	if err := s.TransitionTo(models.ScenarioStateStopped, reason); err != nil {
+		return err
+	}
 
-	ticker := time.NewTicker(1 * time.Second)
+	return scenarioDAO.Save(s)
+}
+
+func (sr *ScenarioRunner) StopAll() {
+	sr.mu.RLock()
+	scenarioIDs := make([]string, 0, len(sr.scenarios)+len(sr.starting))
+	for id := range sr.scenarios {
+		scenarioIDs = append(scenarioIDs, id)
+	}
+	for id := range sr.starting {
+		scenarioIDs = append(scenarioIDs, id)
+	}
+	sr.mu.RUnlock()
+
+	for _, id := range scenarioIDs {
+		_ = sr.Stop(id)
+	}
+}
+
+func (sr *ScenarioRunner) Shutdown(ctx context.Context) error {
+	sr.StopAll()
+
+	done := make(chan struct{})
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				logging.Logger.Info("exercise loop done for scenario", loggingParam)
-				ticker.Stop()
-				return
-
-			case t := <-ticker.C:
-				if t.Before(startTime) {
-					logging.Logger.Debug("not yet time to start exercising scenario", loggingParam)
-					continue
-				}
-
-				numRequestsToFire := rand.Intn(rs.Workload.MaxRequestsPerSecond + 1)
-				logging.Logger.Debug("firing requests now...", loggingParam, zap.Int("requests", numRequestsToFire))
-				var err error
-				for i := 0; i < numRequestsToFire; i++ {
-					op := randOp(rs.Workload.IndexToSearchRatio)
-					switch op {
-					case OpSearch:
-						logging.Logger.Debug("firing search request", loggingParam)
-						err = doSearch(rs.goldenConn, "foo*")
-
-					case OpIndex:
-						logging.Logger.Debug("firing index request", loggingParam)
-						err = doIndex(rs.goldenConn, "foo", randIndexBody())
-					}
-				}
-
-				if err != nil {
-					logging.Logger.Error(err.Error(), loggingParam)
-				}
-			}
-		}
+		sr.loops.Wait()
+		close(done)
 	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scenario loops did not finish before shutdown deadline: %w", ctx.Err())
+	}
 }
 
-func (rs *runningScenario) startValidationLoop(ctx context.Context) {
+func (sr *ScenarioRunner) reserve(scenarioID string) bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, exists := sr.scenarios[scenarioID]; exists {
+		return false
+	}
+	if _, starting := sr.starting[scenarioID]; starting {
+		return false
+	}
+
+	sr.starting[scenarioID] = false
+	return true
+}
+
+func (sr *ScenarioRunner) unreserve(scenarioID string) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	delete(sr.starting, scenarioID)
+}
+
+func (sr *ScenarioRunner) register(rs *runningScenario) bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	stopped := sr.starting[rs.ID]
+	delete(sr.starting, rs.ID)
+	if stopped {
+		return false
+	}
+
+	sr.scenarios[rs.ID] = rs
+	metrics.SetRunningScenarios(len(sr.scenarios))
+	return true
+}
+
+func (sr *ScenarioRunner) stopStarting(scenarioID string) bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, starting := sr.starting[scenarioID]; !starting {
+		return false
+	}
+
+	sr.starting[scenarioID] = true
+	return true
+}
+
+func (sr *ScenarioRunner) unregister(scenarioID string) (*runningScenario, bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	rs, running := sr.scenarios[scenarioID]
+	delete(sr.scenarios, scenarioID)
+	metrics.SetRunningScenarios(len(sr.scenarios))
+	metrics.ForgetScenario(scenarioID)
+	sr.alerter.Forget(scenarioID)
+
+	return rs, running
+}
+
+func (sr *ScenarioRunner) get(scenarioID string) (*runningScenario, bool) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	rs, running := sr.scenarios[scenarioID]
+	return rs, running
+}
+
+func (sr *ScenarioRunner) Get(scenarioID string) (*models.Scenario, bool) {
+	rs, running := sr.lock(scenarioID)
+	if !running {
+		return nil, false
+	}
+	defer rs.mu.Unlock()
+
+	return rs.snapshot(), true
+}
+
+// lock returns the running scenario with its lock held, if it is still running.
+func (sr *ScenarioRunner) lock(scenarioID string) (*runningScenario, bool) {
+	rs, running := sr.get(scenarioID)
+	if !running {
+		return nil, false
+	}
+
+	rs.mu.Lock()
+	if current, running := sr.get(scenarioID); !running || current != rs {
+		rs.mu.Unlock()
+		return nil, false
+	}
+
+	return rs, true
+}
+
+// The caller must hold rs.mu.
+func (rs *runningScenario) startExerciseLoop() {
+	ctx, cancel := context.WithCancel(rs.ctx)
+	rs.exerciseCancelFunc = cancel
+	rs.supervisor.Start(ctx, "exercise", rs.runExerciseLoop)
+}
+
+// The caller must hold rs.mu.
+func (rs *runningScenario) startValidationLoop() {
+	ctx, cancel := context.WithCancel(rs.ctx)
+	rs.validationCancelFunc = cancel
+	rs.supervisor.Start(ctx, "validation", rs.runValidationLoop)
+}
+
+func (rs *runningScenario) runValidationLoop(ctx context.Context) error {
 	validationFrequency := rs.GetValidationFrequency()
 	startAfter := waitFor(*rs.StartedOn, validationFrequency)
 
 	loggingParam := zap.String("scenario", rs.ID)
 	logging.Logger.Info("starting validation loop", loggingParam, zap.Duration("delay", startAfter))
 
-	var timer *time.Timer
-	timer = time.AfterFunc(startAfter, func() {
+	timer := time.NewTimer(startAfter)
+	select {
+	case <-ctx.Done():
+		logging.Logger.Info("stopping validation loop", loggingParam)
+		timer.Stop()
+		return nil
+
+	case <-timer.C:
 		rs.validate()
+	}
+
+	ticker := time.NewTicker(validationFrequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Logger.Info("stopping validation loop", loggingParam)
+			return nil
 
-		ticker := time.NewTicker(validationFrequency)
-		for {
-			select {
-			case <-ctx.Done():
-				logging.Logger.Info("stopping validation loop", loggingParam)
-				ticker.Stop()
-				timer.Stop()
-				return
-
-			case <-ticker.C:
-				rs.validate()
-			}
+		case <-ticker.C:
+			rs.validate()
 		}
-	})
+	}
 }
 
 func (rs *runningScenario) validate() {
 	loggingParam := zap.String("scenario", rs.ID)
 	logging.Logger.Info("running validations...", loggingParam)
-	result := rs.Scenario.Validate(rs.usageConn)
 
-	validationResultDAO := dao.NewValidationResult(rs.stateConn)
+	rs.mu.Lock()
+	s := rs.snapshot()
+	rs.mu.Unlock()
+
+	start := time.Now()
+	result := s.Validate(rs.usageConn)
+	metrics.ObserveValidation(result, time.Since(start))
+
+	validationResultDAO := rs.store.ValidationResults
 	if err := validationResultDAO.Save(result); err != nil {
 		logging.Logger.Error("error saving validation result", loggingParam, zap.Error(err))
 	}
+
+	rs.alerter.Observe(s, result)
+}
+
+// The caller must hold rs.mu.
+func (rs *runningScenario) snapshot() *models.Scenario {
+	s := *rs.Scenario
+	s.PausedIntervals = append([]models.PausedInterval(nil), rs.PausedIntervals...)
+	s.Transitions = append([]models.StateTransition(nil), rs.Transitions...)
+	if rs.AppliedSetupAssets != nil {
+		s.AppliedSetupAssets = make(map[string]string, len(rs.AppliedSetupAssets))
+		for key, hash := range rs.AppliedSetupAssets {
+			s.AppliedSetupAssets[key] = hash
+		}
+	}
+
+	return &s
 }
 
 func waitFor(start time.Time, interval time.Duration) time.Duration {
@@ -288,64 +703,46 @@ func (sr *ScenarioRunner) initUsageClusterConnection() (*usage.Connection, error
 	)
 }
 
-func randOp(indexToSearchRatio int) OpType {
-	ops := make([]OpType, 1+indexToSearchRatio)
-	ops[0] = OpSearch
-	for i := 1; i < len(ops); i++ {
-		ops[i] = OpIndex
+func scopedAPIKeyRoles(s *models.Scenario) map[string]interface{} {
+	target := s.GetWorkloadTarget()
+	names := []string{target, target + "*"}
+	for _, asset := range s.Setup.Assets {
+		if asset.Type == models.SetupAssetSeedData {
+			names = append(names, asset.Name)
+		}
 	}
 
-	randIdx := rand.Intn(len(ops))
-	return ops[randIdx]
-}
-
-func randIndexBody() json.RawMessage {
-	messages := []string{
-		"the quick brown fox",
-		"jumped over the",
-		"lazy dog",
+	return map[string]interface{}{
+		"ecbgd": map[string]interface{}{
+			"cluster": []string{"monitor", "manage_ilm", "manage_index_templates", "manage_pipeline", "manage_slm"},
+			"indices": []map[string]interface{}{
+				{
+					"names":      names,
+					"privileges": []string{"read", "write", "create_index", "view_index_metadata"},
+				},
+			},
+		},
 	}
-
-	innerKeys := []string{"count", "sum"}
-
-	randMsgIdx := rand.Intn(len(messages))
-	randMsg := messages[randMsgIdx]
-
-	randKeyIdx := rand.Intn(len(innerKeys))
-	randKey := innerKeys[randKeyIdx]
-
-	randNum := (17 + rand.Intn(10000)) % 523
-
-	bodyTpl := `{"message":"%s","metric":{"%s":%d}}`
-	body := fmt.Sprintf(bodyTpl, randMsg, randKey, randNum)
-
-	return json.RawMessage(body)
 }
 
-func doSearch(esClient *es.Client, target string) error {
-	if _, err := esClient.Search(
-		esClient.Search.WithIndex(target),
-	); err != nil {
-		return fmt.Errorf("search operation failed: %w", err)
+func scopeCredentials(goldenConn *es.Client, s *models.Scenario) (*es.Client, error) {
+	creds := s.DeploymentCredentials
+	if creds.APIKey != "" || creds.Password == "" {
+		return nil, nil
 	}
 
-	return nil
-}
-
-func doIndex(esClient *es.Client, target string, body json.RawMessage) error {
-	var b bytes.Buffer
-	if len(body) > 0 {
-		b.Write(body)
+	apiKey, err := stack.CreateAPIKey(goldenConn, fmt.Sprintf("ecbgd-%s", s.ID), scopedAPIKeyRoles(s))
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := esClient.Index(
-		target,
-		&b,
-	)
-
+	creds.APIKey = apiKey
+	creds.Password = ""
+	scopedConn, err := deployment.NewClient(creds)
 	if err != nil {
-		return fmt.Errorf("index operation failed: %w", err)
+		return nil, fmt.Errorf("unable to create connection to golden deployment: %w", err)
 	}
 
-	return nil
+	s.DeploymentCredentials = creds
+	return scopedConn, nil
 }
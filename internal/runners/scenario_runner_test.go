@@ -0,0 +1,393 @@
+package runners
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const testScenarios = 8
+
+func TestMain(m *testing.M) {
+	logging.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func newTestStore(t *testing.T) *dao.Store {
+	t.Helper()
+
+	store, err := dao.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create local store: %v", err)
+	}
+
+	return store
+}
+
+// newTestRunner returns a scenario runner with leases enabled that works against
+// fake Elastic Cloud API and Elasticsearch servers, in which every golden
+// deployment exists and is healthy, and that keeps its state in the given store.
+func newTestRunner(t *testing.T, store *dao.Store) *ScenarioRunner {
+	t.Helper()
+
+	return newGatedTestRunner(t, store, nil)
+}
+
+// newGatedTestRunner returns a test scenario runner whose fake Elastic Cloud API
+// only lists deployments once the given gate, if any, is closed.
+func newGatedTestRunner(t *testing.T, store *dao.Store, gate <-chan struct{}) *ScenarioRunner {
+	t.Helper()
+
+	cloud := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/api/v1/deployments" {
+			if gate != nil {
+				<-gate
+			}
+
+			var deployments []map[string]interface{}
+			for i := 0; i < testScenarios; i++ {
+				deployments = append(deployments, map[string]interface{}{
+					"id":        fmt.Sprintf("%032x", i),
+					"name":      fmt.Sprintf("golden-scenario-%d", i),
+					"resources": []interface{}{},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"deployments": deployments})
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/deployments/")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      id,
+			"name":    "golden",
+			"healthy": true,
+			"resources": map[string]interface{}{
+				"elasticsearch": []interface{}{
+					map[string]interface{}{
+						"id":     "cluster",
+						"ref_id": "main-elasticsearch",
+						"region": "test",
+						"info": map[string]interface{}{
+							"cluster_id":    "cluster",
+							"cluster_name":  "cluster",
+							"deployment_id": id,
+							"healthy":       true,
+							"status":        "started",
+							"plan_info":     map[string]interface{}{"healthy": true},
+						},
+					},
+				},
+			},
+		})
+	}))
+	t.Cleanup(cloud.Close)
+
+	elasticsearch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(elasticsearch.Close)
+	t.Setenv("ELASTICSEARCH_URL", elasticsearch.URL)
+
+	cfg := new(config.Config)
+	cfg.API.Url = cloud.URL
+	cfg.API.Key = "test"
+	cfg.UsageCluster.Url = elasticsearch.URL
+	cfg.Leases.Enabled = true
+
+	sr, err := NewScenarioRunner(cfg, store)
+	if err != nil {
+		t.Fatalf("unable to create scenario runner: %v", err)
+	}
+
+	return sr
+}
+
+func newTestScenario(i int) *models.Scenario {
+	s := new(models.Scenario)
+	s.ID = fmt.Sprintf("scenario-%d", i)
+	s.Workload.TargetRequestsPerSecond = 20
+	s.Workload.Workers = 2
+	s.Validations.FrequencySeconds = 3600
+	return s
+}
+
+func TestScenarioRunnerConcurrentStartStopTerminate(t *testing.T) {
+	sr := newTestRunner(t, newTestStore(t))
+
+	var wg sync.WaitGroup
+	for round := 0; round < 5; round++ {
+		for i := 0; i < testScenarios; i++ {
+			i := i
+			id := newTestScenario(i).ID
+
+			// Errors are expected, e.g. when stopping a scenario that was never
+			// started; the runner must neither race, panic nor deadlock.
+			wg.Add(5)
+			go func() {
+				defer wg.Done()
+				sr.Start(newTestScenario(i))
+			}()
+			go func() {
+				defer wg.Done()
+				sr.Pause(id, "test", false, nil)
+			}()
+			go func() {
+				defer wg.Done()
+				sr.Resume(id, "test", nil)
+			}()
+			go func() {
+				defer wg.Done()
+				sr.Stop(id)
+			}()
+			go func() {
+				defer wg.Done()
+				sr.Terminate(id, "test", nil)
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sr.Shutdown(ctx); err != nil {
+		t.Fatalf("unable to shut down scenario runner: %v", err)
+	}
+
+	for i := 0; i < testScenarios; i++ {
+		if _, running := sr.get(newTestScenario(i).ID); running {
+			t.Errorf("scenario [%s] is still running after shutdown", newTestScenario(i).ID)
+		}
+	}
+}
+
+func TestScenarioRunnerAppliesChangesElsewhere(t *testing.T) {
+	store := newTestStore(t)
+	owner := newTestRunner(t, store)
+	other := newTestRunner(t, store)
+	defer owner.Shutdown(context.Background())
+
+	s := newTestScenario(0)
+	if err := owner.Start(s); err != nil {
+		t.Fatalf("unable to start scenario: %v", err)
+	}
+	waitForState(t, owner, s.ID, models.ScenarioStateExercising)
+
+	if _, err := other.Pause(s.ID, "paused elsewhere", true, nil); err != nil {
+		t.Fatalf("unable to pause scenario elsewhere: %v", err)
+	}
+	if err := owner.reconcile(); err != nil {
+		t.Fatalf("unable to reconcile: %v", err)
+	}
+	waitForState(t, owner, s.ID, models.ScenarioStatePaused)
+
+	if _, err := other.Resume(s.ID, "resumed elsewhere", nil); err != nil {
+		t.Fatalf("unable to resume scenario elsewhere: %v", err)
+	}
+	if err := owner.reconcile(); err != nil {
+		t.Fatalf("unable to reconcile: %v", err)
+	}
+	waitForState(t, owner, s.ID, models.ScenarioStateExercising)
+
+	if _, err := other.Terminate(s.ID, "stopped elsewhere", nil); err != nil {
+		t.Fatalf("unable to stop scenario elsewhere: %v", err)
+	}
+	if err := owner.reconcile(); err != nil {
+		t.Fatalf("unable to reconcile: %v", err)
+	}
+	if _, running := owner.get(s.ID); running {
+		t.Fatalf("scenario [%s] that was stopped elsewhere is still running", s.ID)
+	}
+}
+
+// waitForState waits for the scenario with the given ID to reach the given
+// state in the scenario runner.
+func waitForState(t *testing.T, sr *ScenarioRunner, scenarioID string, state models.ScenarioState) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rs, running := sr.lock(scenarioID)
+		if !running {
+			t.Fatalf("scenario [%s] is not running", scenarioID)
+		}
+		current := rs.GetState()
+		rs.mu.Unlock()
+
+		if current == state {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scenario [%s] is [%s] instead of [%s]", scenarioID, current, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScenarioRunnerChecksExpectedVersion(t *testing.T) {
+	store := newTestStore(t)
+	sr := newTestRunner(t, store)
+
+	s := newTestScenario(0)
+	s.TransitionTo(models.ScenarioStateProvisioning, "test")
+	s.TransitionTo(models.ScenarioStateExercising, "test")
+	if err := store.Scenarios.Save(s); err != nil {
+		t.Fatalf("unable to save scenario: %v", err)
+	}
+
+	stale := s.Version
+	stale.SeqNo--
+	if _, err := sr.Pause(s.ID, "test", false, &stale); !errors.Is(err, ErrScenarioChanged) {
+		t.Fatalf("pausing scenario at a stale version: expected [%v], got [%v]", ErrScenarioChanged, err)
+	}
+
+	paused, err := sr.Pause(s.ID, "test", false, &s.Version)
+	if err != nil {
+		t.Fatalf("unable to pause scenario at its current version: %v", err)
+	}
+	if paused.GetState() != models.ScenarioStatePaused {
+		t.Fatalf("scenario is [%s] instead of [%s]", paused.GetState(), models.ScenarioStatePaused)
+	}
+}
+
+// unavailableLeases fails to acquire and release leases once it is unavailable.
+type unavailableLeases struct {
+	dao.LeaseRepository
+	unavailable int32
+}
+
+func (ul *unavailableLeases) TryAcquire(scenarioID, owner string, duration time.Duration) (bool, error) {
+	if atomic.LoadInt32(&ul.unavailable) != 0 {
+		return false, errors.New("unavailable")
+	}
+	return ul.LeaseRepository.TryAcquire(scenarioID, owner, duration)
+}
+
+func (ul *unavailableLeases) Release(scenarioID, owner string) error {
+	if atomic.LoadInt32(&ul.unavailable) != 0 {
+		return errors.New("unavailable")
+	}
+	return ul.LeaseRepository.Release(scenarioID, owner)
+}
+
+func TestScenarioRunnerStopsScenariosWhoseLeaseExpires(t *testing.T) {
+	store := newTestStore(t)
+	leases := &unavailableLeases{LeaseRepository: store.Leases}
+	store.Leases = leases
+	sr := newTestRunner(t, store)
+	defer sr.Shutdown(context.Background())
+
+	s := newTestScenario(0)
+	if err := sr.Start(s); err != nil {
+		t.Fatalf("unable to start scenario: %v", err)
+	}
+	waitForState(t, sr, s.ID, models.ScenarioStateExercising)
+	atomic.StoreInt32(&leases.unavailable, 1)
+
+	// Renewing fails, but the lease has not expired yet
+	if err := sr.reconcile(); err != nil {
+		t.Fatalf("unable to reconcile: %v", err)
+	}
+	if _, running := sr.get(s.ID); !running {
+		t.Fatalf("scenario [%s] was stopped before its lease expired", s.ID)
+	}
+
+	rs, _ := sr.lock(s.ID)
+	rs.leaseExpiresOn = time.Now()
+	rs.mu.Unlock()
+
+	if err := sr.reconcile(); err != nil {
+		t.Fatalf("unable to reconcile: %v", err)
+	}
+	if _, running := sr.get(s.ID); running {
+		t.Fatalf("scenario [%s] whose lease expired is still running", s.ID)
+	}
+}
+
+func TestScenarioRunnerKeepsRunningStateWhenSaveFails(t *testing.T) {
+	store := newTestStore(t)
+	sr := newTestRunner(t, store)
+	defer sr.Shutdown(context.Background())
+
+	s := newTestScenario(0)
+	if err := sr.Start(s); err != nil {
+		t.Fatalf("unable to start scenario: %v", err)
+	}
+	waitForState(t, sr, s.ID, models.ScenarioStateExercising)
+
+	// Change the persisted scenario behind the runner's back
+	persisted, err := store.Scenarios.Get(s.ID)
+	if err != nil {
+		t.Fatalf("unable to get scenario: %v", err)
+	}
+	if err := store.Scenarios.Save(persisted); err != nil {
+		t.Fatalf("unable to save scenario: %v", err)
+	}
+
+	if _, err := sr.Pause(s.ID, "test", false, nil); !errors.Is(err, dao.ErrConflict) {
+		t.Fatalf("pausing scenario that changed: expected [%v], got [%v]", dao.ErrConflict, err)
+	}
+	waitForState(t, sr, s.ID, models.ScenarioStateExercising)
+}
+
+func TestScenarioRunnerStopWhileStarting(t *testing.T) {
+	gate := make(chan struct{})
+	sr := newGatedTestRunner(t, newTestStore(t), gate)
+	defer sr.Shutdown(context.Background())
+
+	s := newTestScenario(0)
+	started := make(chan error, 1)
+	go func() {
+		started <- sr.Start(s)
+	}()
+
+	// Operations on the scenario do not wait for the Elastic Cloud API while it
+	// is starting
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sr.mu.RLock()
+		_, starting := sr.starting[s.ID]
+		sr.mu.RUnlock()
+		if starting {
+			break
+		}
+		if time.Now().After(deadline) {
+			close(gate)
+			t.Fatalf("scenario [%s] is not starting", s.ID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, running := sr.Get(s.ID); running {
+		t.Fatalf("scenario [%s] is running before it started", s.ID)
+	}
+	if err := sr.Stop(s.ID); err != nil {
+		t.Fatalf("unable to stop starting scenario: %v", err)
+	}
+	close(gate)
+
+	if err := <-started; err == nil {
+		t.Fatalf("scenario [%s] that was stopped while starting was started", s.ID)
+	}
+	if _, running := sr.get(s.ID); running {
+		t.Fatalf("scenario [%s] that was stopped while starting is running", s.ID)
+	}
+}
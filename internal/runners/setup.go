@@ -0,0 +1,151 @@
+package runners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/stack"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+)
+
+const (
+	setupAttempts      = 3
+	setupRetryInterval = 30 * time.Second
+)
+
+// setupWithRetries retries setup, as golden deployments may reject requests for
+// a while after becoming healthy.
+func setupWithRetries(ctx context.Context, goldenConn *es.Client, s *models.Scenario, assetsDir string) error {
+	var err error
+	for attempt := 1; attempt <= setupAttempts; attempt++ {
+		if err = setup(goldenConn, s, assetsDir); err == nil {
+			return nil
+		}
+
+		if attempt == setupAttempts {
+			break
+		}
+
+		logging.Logger.Warn("unable to set up golden deployment, retrying",
+			zap.String("scenario", s.ID),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(attempt) * setupRetryInterval):
+		}
+	}
+
+	return err
+}
+
+func setup(goldenConn *es.Client, s *models.Scenario, assetsDir string) error {
+	if err := setupData(goldenConn, s); err != nil {
+		return err
+	}
+
+	if err := setupSnapshots(goldenConn, s); err != nil {
+		return err
+	}
+
+	return setupAssets(goldenConn, s, assetsDir)
+}
+
+func setupData(goldenConn *es.Client, s *models.Scenario) error {
+	if s.Data == nil {
+		return nil
+	}
+
+	logging.Logger.Info("setting up test data storage in golden deployment",
+		zap.String("scenario", s.ID),
+		zap.String("data_stream", s.Data.GetDataStream()),
+	)
+
+	if err := stack.PutILMPolicy(goldenConn, s.Data.GetILMPolicyName(), s.Data.ILMPolicy()); err != nil {
+		return fmt.Errorf("unable to set up test data storage for scenario [%s]: %w", s.ID, err)
+	}
+
+	if err := stack.PutIndexTemplate(goldenConn, s.Data.GetIndexTemplateName(), s.Data.IndexTemplate()); err != nil {
+		return fmt.Errorf("unable to set up test data storage for scenario [%s]: %w", s.ID, err)
+	}
+
+	if err := stack.EnsureDataStream(goldenConn, s.Data.GetDataStream()); err != nil {
+		return fmt.Errorf("unable to set up test data storage for scenario [%s]: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+func setupSnapshots(goldenConn *es.Client, s *models.Scenario) error {
+	if s.Snapshots == nil {
+		return nil
+	}
+
+	logging.Logger.Info("setting up snapshot policy in golden deployment",
+		zap.String("scenario", s.ID),
+		zap.String("policy", s.Snapshots.GetPolicyName()),
+	)
+
+	if err := stack.PutSLMPolicy(goldenConn, s.Snapshots.GetPolicyName(), s.Snapshots.SLMPolicy()); err != nil {
+		return fmt.Errorf("unable to set up snapshot policy for scenario [%s]: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+// setupAssets applies the setup assets that changed since they were last
+// applied.
+func setupAssets(goldenConn *es.Client, s *models.Scenario, assetsDir string) error {
+	for _, asset := range s.Setup.Assets {
+		contents, err := asset.Contents(assetsDir)
+		if err != nil {
+			return err
+		}
+
+		hash := models.Hash(contents)
+		if s.AppliedSetupAssets[asset.Key()] == hash {
+			logging.Logger.Debug("setup asset already applied",
+				zap.String("scenario", s.ID), zap.String("asset", asset.Key()),
+			)
+			continue
+		}
+
+		logging.Logger.Info("applying setup asset to golden deployment",
+			zap.String("scenario", s.ID), zap.String("asset", asset.Key()),
+		)
+		if err := applyAsset(goldenConn, asset, contents); err != nil {
+			return fmt.Errorf("unable to apply setup asset [%s] for scenario [%s]: %w", asset.Key(), s.ID, err)
+		}
+
+		if s.AppliedSetupAssets == nil {
+			s.AppliedSetupAssets = map[string]string{}
+		}
+		s.AppliedSetupAssets[asset.Key()] = hash
+	}
+
+	return nil
+}
+
+func applyAsset(goldenConn *es.Client, asset models.SetupAsset, contents []byte) error {
+	switch asset.Type {
+	case models.SetupAssetIndexTemplate:
+		return stack.PutIndexTemplate(goldenConn, asset.Name, json.RawMessage(contents))
+	case models.SetupAssetIngestPipeline:
+		return stack.PutIngestPipeline(goldenConn, asset.Name, contents)
+	case models.SetupAssetILMPolicy:
+		return stack.PutILMPolicy(goldenConn, asset.Name, json.RawMessage(contents))
+	case models.SetupAssetSeedData:
+		return stack.LoadSeedData(goldenConn, asset.Name, contents)
+	default:
+		return asset.Validate()
+	}
+}
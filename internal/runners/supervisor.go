@@ -0,0 +1,139 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+)
+
+const (
+	minRestartBackoff = 1 * time.Second
+	maxRestartBackoff = 5 * time.Minute
+
+	stableRunDuration = 10 * time.Minute
+)
+
+type loop struct {
+	name    string
+	ctx     context.Context
+	run     func(ctx context.Context) error
+	backoff time.Duration
+}
+
+type loopExit struct {
+	loop   *loop
+	err    error
+	ranFor time.Duration
+}
+
+// supervisor runs a scenario's loops and restarts them, with exponential
+// backoff, when they crash.
+type supervisor struct {
+	scenarioID string
+	exits      chan loopExit
+	ctx        context.Context
+
+	running *sync.WaitGroup
+}
+
+func newSupervisor(ctx context.Context, scenarioID string, running *sync.WaitGroup) *supervisor {
+	sv := new(supervisor)
+	sv.scenarioID = scenarioID
+	sv.exits = make(chan loopExit)
+	sv.ctx = ctx
+	sv.running = running
+
+	go sv.supervise()
+
+	return sv
+}
+
+func (sv *supervisor) Start(ctx context.Context, name string, run func(ctx context.Context) error) {
+	sv.launch(&loop{
+		name:    name,
+		ctx:     ctx,
+		run:     run,
+		backoff: minRestartBackoff,
+	})
+}
+
+func (sv *supervisor) launch(l *loop) {
+	sv.running.Add(1)
+	go sv.run(l)
+}
+
+// run runs the loop, which must already be counted as running.
+func (sv *supervisor) run(l *loop) {
+	started := time.Now()
+	err := runRecovered(l.ctx, l.run)
+
+	select {
+	case sv.exits <- loopExit{loop: l, err: err, ranFor: time.Since(started)}:
+	case <-sv.ctx.Done():
+		sv.running.Done()
+	}
+}
+
+func (sv *supervisor) supervise() {
+	for {
+		select {
+		case <-sv.ctx.Done():
+			return
+
+		case exit := <-sv.exits:
+			l := exit.loop
+			if l.ctx.Err() != nil || exit.err == nil {
+				sv.running.Done()
+				continue
+			}
+
+			if exit.ranFor >= stableRunDuration {
+				l.backoff = minRestartBackoff
+			}
+
+			logging.Logger.Error("scenario loop crashed, restarting",
+				zap.String("scenario", sv.scenarioID),
+				zap.String("loop", l.name),
+				zap.Duration("backoff", l.backoff),
+				zap.Error(exit.err),
+			)
+
+			delay := l.backoff
+			l.backoff *= 2
+			if l.backoff > maxRestartBackoff {
+				l.backoff = maxRestartBackoff
+			}
+
+			// The pending restart keeps the loop counted as running
+			go func() {
+				timer := time.NewTimer(delay)
+				defer timer.Stop()
+
+				select {
+				case <-timer.C:
+					sv.run(l)
+				case <-l.ctx.Done():
+					sv.running.Done()
+				case <-sv.ctx.Done():
+					sv.running.Done()
+				}
+			}()
+		}
+	}
+}
+
+func runRecovered(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	return run(ctx)
+}
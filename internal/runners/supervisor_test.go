@@ -0,0 +1,73 @@
+package runners
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisorRestartsCrashedLoops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running sync.WaitGroup
+	sv := newSupervisor(ctx, "scenario", &running)
+
+	runs := make(chan int, 2)
+	var n int
+	sv.Start(ctx, "crashing", func(ctx context.Context) error {
+		n++
+		runs <- n
+		if n == 1 {
+			panic("crash")
+		}
+		<-ctx.Done()
+		return nil
+	})
+
+	for want := 1; want <= 2; want++ {
+		select {
+		case got := <-runs:
+			if got != want {
+				t.Fatalf("expected run %d, got run %d", want, got)
+			}
+		case <-time.After(2 * minRestartBackoff):
+			t.Fatalf("supervisor did not restart the crashed loop")
+		}
+	}
+}
+
+func TestSupervisorWaitsForPendingRestarts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var running sync.WaitGroup
+	sv := newSupervisor(ctx, "scenario", &running)
+
+	crashed := make(chan struct{}, 1)
+	sv.Start(ctx, "crashing", func(ctx context.Context) error {
+		select {
+		case crashed <- struct{}{}:
+		default:
+		}
+		return errors.New("crash")
+	})
+
+	// Stop the scenario while the crashed loop's restart is pending
+	<-crashed
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(minRestartBackoff / 2):
+		t.Fatal("supervisor did not release the pending restart of a stopped loop")
+	}
+}
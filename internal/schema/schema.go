@@ -0,0 +1,130 @@
+// Package schema derives the mappings of the state cluster's indices from the
+// models stored in them.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+// Field mapping overrides, set with the `es` struct tag
+const (
+	tagText     = "text"
+	tagDynamic  = "dynamic"
+	tagDisabled = "disabled"
+)
+
+var Indices = map[string]interface{}{
+	"gds-audit-log":          models.AuditEvent{},
+	"gds-deployment-configs": models.DeploymentConfiguration{},
+	"gds-scenario-leases":    models.Lease{},
+	"gds-scenarios":          models.Scenario{},
+	"gds-validation-results": models.ValidationResult{},
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func Mappings(model interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"dynamic":    "strict",
+		"properties": properties(reflect.TypeOf(model)),
+	}
+}
+
+func MappingsFor(index string) (map[string]interface{}, bool) {
+	model, ok := Indices[index]
+	if !ok {
+		return nil, false
+	}
+
+	return Mappings(model), true
+}
+
+func properties(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		fieldType := indirect(field.Type)
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			// Embedded structs' fields are encoded inline
+			for k, v := range properties(fieldType) {
+				props[k] = v
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		props[name] = mapping(field.Type, field.Tag.Get("es"))
+	}
+
+	return props
+}
+
+func mapping(t reflect.Type, tag string) map[string]interface{} {
+	switch tag {
+	case tagText:
+		return map[string]interface{}{"type": "text"}
+	case tagDynamic:
+		return map[string]interface{}{"type": "object", "dynamic": true}
+	case tagDisabled:
+		return map[string]interface{}{"type": "object", "enabled": false}
+	}
+
+	if t == rawMessageType {
+		return map[string]interface{}{"type": "object", "enabled": false}
+	}
+
+	t = indirect(t)
+	if t == timeType {
+		return map[string]interface{}{"type": "date"}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		// Arrays are mapped like their elements
+		return mapping(t.Elem(), tag)
+	case reflect.String:
+		return map[string]interface{}{"type": "keyword"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "long"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "float"}
+	case reflect.Struct:
+		return map[string]interface{}{"properties": properties(t)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "dynamic": true}
+	default:
+		return map[string]interface{}{"type": "object", "enabled": false}
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
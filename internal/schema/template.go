@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type indexTemplate struct {
+	IndexPatterns json.RawMessage `json:"index_patterns"`
+	DataStream    json.RawMessage `json:"data_stream,omitempty"`
+	Template      struct {
+		Settings json.RawMessage `json:"settings,omitempty"`
+		Mappings interface{}     `json:"mappings"`
+	} `json:"template"`
+}
+
+func RenderIndexTemplate(index string, body []byte) ([]byte, error) {
+	mappings, ok := MappingsFor(index)
+	if !ok {
+		return body, nil
+	}
+
+	var tpl indexTemplate
+	if err := json.Unmarshal(body, &tpl); err != nil {
+		return nil, fmt.Errorf("unable to parse index template [%s]: %w", index, err)
+	}
+	tpl.Template.Mappings = mappings
+
+	rendered, err := json.MarshalIndent(tpl, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode index template [%s] as JSON: %w", index, err)
+	}
+
+	return append(rendered, '\n'), nil
+}
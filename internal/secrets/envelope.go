@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+type Envelope struct {
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func Seal(kms KMS, plaintext []byte) (*Envelope, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("unable to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := kms.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key: %w", err)
+	}
+
+	return &Envelope{
+		KeyID:      kms.KeyID(),
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func Open(kms KMS, envelope *Envelope) ([]byte, error) {
+	dataKey, err := kms.UnwrapKey(envelope.KeyID, envelope.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(aead, envelope.Ciphertext)
+}
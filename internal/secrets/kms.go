@@ -0,0 +1,136 @@
+// Package secrets encrypts secrets with envelope encryption before they are
+// persisted.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+)
+
+type KMS interface {
+	KeyID() string
+
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+const keySize = 32
+
+type LocalKMS struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+func NewLocalKMS(key []byte) (*LocalKMS, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes long, got %d", keySize, len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Data keys wrapped with a different key are detected by their key ID
+	sum := sha256.Sum256(key)
+
+	k := new(LocalKMS)
+	k.keyID = "local:" + hex.EncodeToString(sum[:8])
+	k.aead = aead
+
+	return k, nil
+}
+
+func (k *LocalKMS) KeyID() string {
+	return k.keyID
+}
+
+func (k *LocalKMS) WrapKey(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey)
+}
+
+func (k *LocalKMS) UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	if keyID != k.keyID {
+		return nil, fmt.Errorf("data key was wrapped with key [%s], not with configured key [%s]", keyID, k.keyID)
+	}
+
+	return open(k.aead, wrappedKey)
+}
+
+func NewKMS(cfg *config.Config) (KMS, error) {
+	if cfg.Encryption.Key == "" && cfg.Encryption.KeyFile == "" {
+		return nil, nil
+	}
+
+	switch cfg.GetEncryptionKMS() {
+	case config.KMSTypeLocal:
+		key, err := localKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewLocalKMS(key)
+
+	default:
+		return nil, fmt.Errorf("unknown KMS type [%s]", cfg.Encryption.KMS)
+	}
+}
+
+func localKey(cfg *config.Config) ([]byte, error) {
+	encoded, err := config.ReadSecret(cfg.Encryption.Key, cfg.Encryption.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read encryption key: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode encryption key as base64: %w", err)
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+// audit is a middleware that writes every request that may change the service's
+// state to the audit log, once it has been handled, including requests that
+// were rejected.
+func audit(auditLog dao.AuditLogRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutating(c.Request.Method) {
+			return
+		}
+
+		event := models.AuditEvent{
+			Timestamp: time.Now(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			ClientIP:  c.ClientIP(),
+		}
+
+		c.Next()
+
+		event.Status = c.Writer.Status()
+		if principal, ok := getPrincipal(c); ok {
+			event.Principal = principal.Name
+			event.Role = string(principal.Role)
+			event.AuthMethod = principal.Method
+		}
+		if err := c.Errors.Last(); err != nil {
+			event.Error = err.Error()
+		}
+
+		if err := auditLog.Save(&event); err != nil {
+			logging.Logger.Error("unable to write audit event",
+				zap.String("method", event.Method),
+				zap.String("path", event.Path),
+				zap.String("principal", event.Principal),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/auth"
+)
+
+const (
+	headerAuthorization   = "Authorization"
+	headerWWWAuthenticate = "WWW-Authenticate"
+
+	contextKeyPrincipal = "principal"
+)
+
+func authenticate(authenticators map[string]auth.Authenticator) gin.HandlerFunc {
+	var schemes []string
+	for scheme := range authenticators {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	return func(c *gin.Context) {
+		if len(authenticators) == 0 {
+			c.Set(contextKeyPrincipal, auth.Anonymous)
+			return
+		}
+
+		scheme, credentials, _ := cut(c.GetHeader(headerAuthorization), " ")
+		authenticator, ok := authenticators[scheme]
+		if !ok || credentials == "" {
+			c.Header(headerWWWAuthenticate, strings.Join(schemes, ", "))
+			abortWithError(c, "could not authenticate request", auth.ErrUnauthenticated)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(credentials)
+		if err != nil {
+			c.Header(headerWWWAuthenticate, strings.Join(schemes, ", "))
+			abortWithError(c, "could not authenticate request", err)
+			return
+		}
+
+		c.Set(contextKeyPrincipal, principal)
+	}
+}
+
+func authorize(c *gin.Context) {
+	required := auth.RoleOperator
+	if !isMutating(c.Request.Method) {
+		required = auth.RoleViewer
+	}
+
+	principal, ok := getPrincipal(c)
+	if !ok || !principal.Role.Allows(required) {
+		abortWithError(c, "could not authorize request", auth.ErrForbidden)
+		return
+	}
+}
+
+func getPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	v, ok := c.Get(contextKeyPrincipal)
+	if !ok {
+		return nil, false
+	}
+
+	principal, ok := v.(*auth.Principal)
+	return principal, ok
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+len(sep):]), true
+	}
+
+	return s, "", false
+}
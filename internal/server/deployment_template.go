@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -8,41 +9,54 @@ import (
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
 
-	es "github.com/elastic/go-elasticsearch/v7"
-
 	"github.com/gin-gonic/gin"
 )
 
-func registerDeploymentConfigurationRoutes(r *gin.Engine, stateConn *es.Client) {
-	r.PUT("/deployment_config/:id", putDeploymentConfiguration(stateConn))
-	r.GET("/deployment_configs", getDeploymentConfigurations(stateConn))
-	r.GET("/deployment_config/:id", getDeploymentConfiguration(stateConn))
+func registerDeploymentConfigurationRoutes(r gin.IRoutes, deploymentConfigDAO dao.DeploymentConfigurationRepository) {
+	r.PUT("/deployment_config/:id", putDeploymentConfiguration(deploymentConfigDAO))
+	r.GET("/deployment_configs", getDeploymentConfigurations(deploymentConfigDAO))
+	r.GET("/deployment_config/:id", getDeploymentConfiguration(deploymentConfigDAO))
 	r.DELETE("/deployment_config/:id")
 }
 
-func putDeploymentConfiguration(stateConn *es.Client) func(c *gin.Context) {
-	deploymentConfigDAO := dao.NewDeploymentConfiguration(stateConn)
+func putDeploymentConfiguration(deploymentConfigDAO dao.DeploymentConfigurationRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var deploymentConfig models.DeploymentConfiguration
 
 		if err := c.ShouldBindJSON(&deploymentConfig); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "could not parse deployment configuration",
-				"cause": err.Error(),
-			})
+			abortWithBadRequest(c, "could not parse deployment configuration", err)
 			return
 		}
 		deploymentConfig.ID = id
 
+		current, err := deploymentConfigDAO.Get(id)
+		if err != nil && !errors.Is(err, dao.ErrNotFound) {
+			abortWithError(c, "could not read deployment configuration", err)
+			return
+		}
+
+		// Replace the current configuration, unless it changes concurrently
+		var currentVersion *models.Version
+		if current != nil {
+			currentVersion = &current.Version
+			deploymentConfig.Version = current.Version
+		}
+		if !checkIfMatch(c, currentVersion) {
+			return
+		}
+
 		if err := deploymentConfigDAO.Save(&deploymentConfig); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not save deployment configuration",
-				"cause": err.Error(),
-			})
+			// Conditional requests that lose a race fail their precondition
+			if errors.Is(err, dao.ErrConflict) && c.GetHeader(headerIfMatch) != "" {
+				abortWithPreconditionFailed(c)
+				return
+			}
+			abortWithError(c, "could not save deployment configuration", err)
 			return
 		}
 
+		setETag(c, deploymentConfig.Version)
 		c.JSON(http.StatusOK, gin.H{
 			"id": id,
 			"resources": []string{
@@ -52,15 +66,11 @@ func putDeploymentConfiguration(stateConn *es.Client) func(c *gin.Context) {
 	}
 }
 
-func getDeploymentConfigurations(stateConn *es.Client) func(c *gin.Context) {
-	deploymentConfigDAO := dao.NewDeploymentConfiguration(stateConn)
+func getDeploymentConfigurations(deploymentConfigDAO dao.DeploymentConfigurationRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		deploymentConfigs, err := deploymentConfigDAO.ListAll()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not read deployment configurations",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not read deployment configurations", err)
 			return
 		}
 
@@ -85,20 +95,17 @@ func getDeploymentConfigurations(stateConn *es.Client) func(c *gin.Context) {
 	}
 }
 
-func getDeploymentConfiguration(stateConn *es.Client) func(c *gin.Context) {
-	deploymentConfigDAO := dao.NewDeploymentConfiguration(stateConn)
+func getDeploymentConfiguration(deploymentConfigDAO dao.DeploymentConfigurationRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
 		deploymentConfig, err := deploymentConfigDAO.Get(id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not read deployment configuration",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not read deployment configuration", err)
 			return
 		}
 
+		setETag(c, deploymentConfig.Version)
 		c.JSON(http.StatusOK, deploymentConfig)
 	}
 }
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/auth"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/runners"
+)
+
+func handleErrors(c *gin.Context) {
+	c.Next()
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	err := c.Errors.Last()
+	message, _ := err.Meta.(string)
+	c.JSON(errorStatus(err), gin.H{
+		"error": message,
+		"cause": err.Err.Error(),
+	})
+}
+
+func abortWithError(c *gin.Context, message string, err error) {
+	c.Error(err).SetMeta(message)
+	c.Abort()
+}
+
+func abortWithBadRequest(c *gin.Context, message string, err error) {
+	c.Error(err).SetType(gin.ErrorTypeBind).SetMeta(message)
+	c.Abort()
+}
+
+func errorStatus(err *gin.Error) int {
+	if err.IsType(gin.ErrorTypeBind) {
+		return http.StatusBadRequest
+	}
+
+	var transitionErr *models.InvalidTransitionError
+	switch {
+	case errors.Is(err.Err, errRequestTooLarge):
+		return http.StatusRequestEntityTooLarge
+
+	case errors.Is(err.Err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+
+	case errors.Is(err.Err, auth.ErrForbidden):
+		return http.StatusForbidden
+
+	case errors.Is(err.Err, dao.ErrNotFound):
+		return http.StatusNotFound
+
+	case errors.Is(err.Err, runners.ErrScenarioChanged):
+		return http.StatusPreconditionFailed
+
+	case errors.Is(err.Err, dao.ErrConflict):
+		return http.StatusConflict
+
+	case errors.As(err.Err, &transitionErr),
+		errors.Is(err.Err, runners.ErrScenarioNotRunning),
+		errors.Is(err.Err, runners.ErrScenarioAlreadyRunning),
+		errors.Is(err.Err, runners.ErrScenarioOwnedElsewhere):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+func etag(version models.Version) string {
+	return fmt.Sprintf(`"%d-%d"`, version.PrimaryTerm, version.SeqNo)
+}
+
+func setETag(c *gin.Context, version models.Version) {
+	if version.IsZero() {
+		return
+	}
+
+	c.Header(headerETag, etag(version))
+}
+
+// checkIfMatch responds with 412 Precondition Failed and returns false unless
+// the request's If-Match header matches the current version, if any.
+func checkIfMatch(c *gin.Context, current *models.Version) bool {
+	ifMatch := c.GetHeader(headerIfMatch)
+	if ifMatch == "" {
+		return true
+	}
+
+	if current != nil {
+		for _, tag := range strings.Split(ifMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == etag(*current) {
+				return true
+			}
+		}
+	}
+
+	abortWithPreconditionFailed(c)
+	return false
+}
+
+func abortWithPreconditionFailed(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{
+		"error": "resource was changed",
+		"cause": fmt.Sprintf("resource does not match [%s: %s]", headerIfMatch, c.GetHeader(headerIfMatch)),
+	})
+}
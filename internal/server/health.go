@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/metrics"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/runners"
+)
+
+func registerHealthRoutes(r gin.IRoutes, scenarioRunner *runners.ScenarioRunner, store *dao.Store) {
+	r.GET("/healthz", getHealthz)
+	r.GET("/readyz", getReadyz(scenarioRunner, store))
+}
+
+func registerMetricsRoute(r gin.IRoutes) {
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
+
+func getHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+func getReadyz(scenarioRunner *runners.ScenarioRunner, store *dao.Store) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		checks := map[string]func() error{
+			"store":           store.Ping,
+			"usage_cluster":   scenarioRunner.PingUsageCluster,
+			"scenario_runner": scenarioRunner.Ready,
+		}
+
+		status := http.StatusOK
+		results := gin.H{}
+		for name, check := range checks {
+			if err := check(); err != nil {
+				status = http.StatusServiceUnavailable
+				results[name] = err.Error()
+				continue
+			}
+			results[name] = "ok"
+		}
+
+		c.JSON(status, gin.H{
+			"ready":  status == http.StatusOK,
+			"checks": results,
+		})
+	}
+}
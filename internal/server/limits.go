@@ -0,0 +1,25 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errRequestTooLarge = errors.New("request body is too large")
+
+// limitBodySize is a middleware that rejects requests whose body is larger than
+// the given number of bytes. Bodies of unknown length fail to be read past the
+// limit.
+func limitBodySize(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			abortWithError(c, fmt.Sprintf("request body may not be larger than %d bytes", maxBytes), errRequestTooLarge)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	}
+}
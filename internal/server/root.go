@@ -2,7 +2,7 @@ package server
 
 import "github.com/gin-gonic/gin"
 
-func registerRootRoute(r *gin.Engine) {
+func registerRootRoute(r gin.IRoutes) {
 	r.GET("/", getRoot)
 }
 
@@ -12,6 +12,7 @@ func getRoot(c *gin.Context) {
 			"/deployment_configs",
 			//"/workloads",
 			"/scenarios",
+			"/metrics",
 		},
 	})
 }
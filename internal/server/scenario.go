@@ -3,72 +3,80 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
+	"go.uber.org/zap"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/runners"
 
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
 
-	es "github.com/elastic/go-elasticsearch/v7"
-
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func registerScenarioRoutes(r *gin.Engine, scenarioRunner *runners.ScenarioRunner, stateConn *es.Client) {
-	r.POST("/scenarios", postScenarios(scenarioRunner, stateConn))
-	r.GET("/scenarios", getScenarios(stateConn))
-	r.GET("/scenario/:id", getScenario(stateConn))
-	r.DELETE("/scenario/:id")
+func registerScenarioRoutes(r gin.IRoutes, scenarioRunner *runners.ScenarioRunner, scenarioDAO dao.ScenarioRepository) {
+	r.POST("/scenarios", postScenarios(scenarioRunner, scenarioDAO))
+	r.GET("/scenarios", getScenarios(scenarioDAO))
+	r.GET("/scenario/:id", getScenario(scenarioDAO))
+	r.DELETE("/scenario/:id", deleteScenario(scenarioRunner, scenarioDAO))
+	r.POST("/scenario/:id/pause", pauseScenario(scenarioRunner, scenarioDAO))
+	r.POST("/scenario/:id/resume", resumeScenario(scenarioRunner, scenarioDAO))
 }
 
-func postScenarios(scenarioRunner *runners.ScenarioRunner, stateConn *es.Client) func(c *gin.Context) {
-	scenarioDAO := dao.NewScenario(stateConn)
+func postScenarios(scenarioRunner *runners.ScenarioRunner, scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		var scenario models.Scenario
 		if err := c.ShouldBindJSON(&scenario); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "could not parse scenario",
-				"cause": err.Error(),
-			})
+			abortWithBadRequest(c, "could not parse scenario", err)
+			return
+		}
+
+		if err := scenarioRunner.Validate(&scenario); err != nil {
+			abortWithBadRequest(c, "could not parse scenario", err)
 			return
 		}
 
 		if err := scenario.GenerateID(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not generate ID for scenario",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not generate ID for scenario", err)
+			return
+		}
+
+		if err := scenario.TransitionTo(models.ScenarioStatePending, "scenario created"); err != nil {
+			abortWithError(c, "could not create scenario", err)
 			return
 		}
 
 		if err := scenarioDAO.Save(&scenario); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not save scenario",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not save scenario", err)
 			return
 		}
 
 		if err := scenarioRunner.Start(&scenario); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not start scenario",
-				"cause": err.Error(),
-			})
+			if tErr := scenario.TransitionTo(models.ScenarioStateFailed, err.Error()); tErr == nil {
+				if sErr := scenarioDAO.Save(&scenario); sErr != nil {
+					logging.Logger.Error("unable to save failed scenario", zap.String("scenario", scenario.ID), zap.Error(sErr))
+				}
+			}
+
+			abortWithError(c, "could not start scenario", err)
 			return
 		}
 
-		if err := scenarioDAO.Save(&scenario); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"id":    scenario.ID,
-				"error": "scenario started but could not be saved",
-				"cause": err.Error(),
-			})
-			return
+		current, running := scenarioRunner.Get(scenario.ID)
+		if !running {
+			var err error
+			if current, err = scenarioDAO.Get(scenario.ID); err != nil {
+				abortWithError(c, "could not read scenario", err)
+				return
+			}
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
-			"id": scenario.ID,
+			"id":     scenario.ID,
+			"status": current.GetState(),
 			"resources": []string{
 				fmt.Sprintf("/scenario/%s", scenario.ID),
 			},
@@ -76,27 +84,25 @@ func postScenarios(scenarioRunner *runners.ScenarioRunner, stateConn *es.Client)
 	}
 }
 
-func getScenarios(stateConn *es.Client) func(c *gin.Context) {
-	scenarioDAO := dao.NewScenario(stateConn)
+func getScenarios(scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		scenarios, err := scenarioDAO.ListAll()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not read scenarios",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not read scenarios", err)
 			return
 		}
 
 		type item struct {
-			ID        string   `json:"id"`
-			Resources []string `json:"resources"`
+			ID        string               `json:"id"`
+			Status    models.ScenarioState `json:"status,omitempty"`
+			Resources []string             `json:"resources"`
 		}
 
 		var items []item
 		for _, scenario := range scenarios {
 			items = append(items, item{
-				ID: scenario.ID,
+				ID:     scenario.ID,
+				Status: scenario.GetState(),
 				Resources: []string{
 					fmt.Sprintf("/scenario/%s", scenario.ID),
 				},
@@ -109,20 +115,138 @@ func getScenarios(stateConn *es.Client) func(c *gin.Context) {
 	}
 }
 
-func getScenario(stateConn *es.Client) func(c *gin.Context) {
-	scenarioDAO := dao.NewScenario(stateConn)
+func getScenario(scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
 		scenario, err := scenarioDAO.Get(id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "could not read scenario",
-				"cause": err.Error(),
-			})
+			abortWithError(c, "could not read scenario", err)
 			return
 		}
 
-		c.JSON(http.StatusOK, scenario)
+		setETag(c, scenario.Version)
+		c.JSON(http.StatusOK, scenario.Redacted())
+	}
+}
+
+func deleteScenario(scenarioRunner *runners.ScenarioRunner, scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		expected, ok := checkScenarioIfMatch(c, scenarioDAO, id)
+		if !ok {
+			return
+		}
+
+		scenario, err := scenarioRunner.Terminate(id, "stopped through API", expected)
+		if err != nil {
+			abortWithError(c, "could not stop scenario", err)
+			return
+		}
+
+		setETag(c, scenario.Version)
+		c.JSON(http.StatusOK, scenarioStatusResponse(scenario))
+	}
+}
+
+func pauseScenario(scenarioRunner *runners.ScenarioRunner, scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		var req struct {
+			Reason      string `json:"reason"`
+			Validations bool   `json:"validations"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				abortWithBadRequest(c, "could not parse pause request", err)
+				return
+			}
+		}
+		if req.Reason == "" {
+			req.Reason = "paused through API"
+		}
+
+		expected, ok := checkScenarioIfMatch(c, scenarioDAO, id)
+		if !ok {
+			return
+		}
+
+		scenario, err := scenarioRunner.Pause(id, req.Reason, req.Validations, expected)
+		if err != nil {
+			abortWithError(c, "could not pause scenario", err)
+			return
+		}
+
+		setETag(c, scenario.Version)
+		c.JSON(http.StatusOK, scenarioStatusResponse(scenario))
+	}
+}
+
+func resumeScenario(scenarioRunner *runners.ScenarioRunner, scenarioDAO dao.ScenarioRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				abortWithBadRequest(c, "could not parse resume request", err)
+				return
+			}
+		}
+		if req.Reason == "" {
+			req.Reason = "resumed through API"
+		}
+
+		expected, ok := checkScenarioIfMatch(c, scenarioDAO, id)
+		if !ok {
+			return
+		}
+
+		scenario, err := scenarioRunner.Resume(id, req.Reason, expected)
+		if err != nil {
+			abortWithError(c, "could not resume scenario", err)
+			return
+		}
+
+		setETag(c, scenario.Version)
+		c.JSON(http.StatusOK, scenarioStatusResponse(scenario))
+	}
+}
+
+// checkScenarioIfMatch returns the version matched by the request's If-Match
+// header, or nil if there is none.
+func checkScenarioIfMatch(c *gin.Context, scenarioDAO dao.ScenarioRepository, id string) (*models.Version, bool) {
+	ifMatch := c.GetHeader(headerIfMatch)
+	if ifMatch == "" {
+		return nil, true
+	}
+
+	scenario, err := scenarioDAO.Get(id)
+	if err != nil {
+		abortWithError(c, "could not read scenario", err)
+		return nil, false
+	}
+
+	if !checkIfMatch(c, &scenario.Version) {
+		return nil, false
+	}
+	if strings.TrimSpace(ifMatch) == "*" {
+		return nil, true
+	}
+
+	return &scenario.Version, true
+}
+
+func scenarioStatusResponse(scenario *models.Scenario) gin.H {
+	return gin.H{
+		"id":     scenario.ID,
+		"status": scenario.GetState(),
+		"resources": []string{
+			fmt.Sprintf("/scenario/%s", scenario.ID),
+		},
 	}
 }
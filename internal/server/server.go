@@ -1,24 +1,93 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"time"
 
-	es "github.com/elastic/go-elasticsearch/v7"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/auth"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/dao"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/logging"
 	"github.com/ycombinator/cloud-billing-golden-deployment/internal/runners"
 )
 
-func Start(scenarioRunner *runners.ScenarioRunner, stateConn *es.Client) error {
+// New returns the API server. If TLS is configured, it should be started with
+// ListenAndServeTLS.
+func New(cfg *config.Config, scenarioRunner *runners.ScenarioRunner, store *dao.Store, authenticators map[string]auth.Authenticator) (*http.Server, error) {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(ginzap.Ginzap(logging.Logger, time.RFC3339, true))
+	r.Use(audit(store.AuditLog))
+	r.Use(handleErrors)
+	r.Use(limitBodySize(cfg.GetServerMaxBodyBytes()))
 
 	// Routes
-	registerRootRoute(r)
-	registerDeploymentConfigurationRoutes(r, stateConn)
-	registerScenarioRoutes(r, scenarioRunner, stateConn)
+	registerHealthRoutes(r, scenarioRunner, store)
 
-	return r.Run("localhost:8111")
+	api := r.Group("/", authenticate(authenticators), authorize)
+	registerRootRoute(api)
+	registerMetricsRoute(api)
+	registerDeploymentConfigurationRoutes(api, store.DeploymentConfigurations)
+	registerScenarioRoutes(api, scenarioRunner, store.Scenarios)
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:              cfg.GetServerAddress(),
+		Handler:           r,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       cfg.GetServerReadTimeout(),
+		ReadHeaderTimeout: cfg.GetServerReadTimeout(),
+		WriteTimeout:      cfg.GetServerWriteTimeout(),
+		IdleTimeout:       cfg.GetServerIdleTimeout(),
+	}, nil
+}
+
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certFile, keyFile, clientCAFile := cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, cfg.Server.TLS.ClientCAFile
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("client CA [%s] is set, but TLS is not enabled", clientCAFile)
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key must be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load TLS certificate [%s] and key [%s]: %w", certFile, keyFile, err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA [%s]: %w", clientCAFile, err)
+		}
+
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA [%s]", clientCAFile)
+		}
+
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
 }
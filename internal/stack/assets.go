@@ -0,0 +1,107 @@
+package stack
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+func PutIngestPipeline(conn *es.Client, name string, pipeline json.RawMessage) error {
+	res, err := conn.Ingest.PutPipeline(name, bytes.NewReader(pipeline))
+	if err != nil {
+		return fmt.Errorf("unable to put ingest pipeline [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
+
+// Seed document IDs are derived from their contents and position, so loading the
+// same seed data again does not duplicate it.
+func LoadSeedData(conn *es.Client, target string, ndjson []byte) error {
+	var body bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(ndjson))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		doc := bytes.TrimSpace(scanner.Bytes())
+		if len(doc) == 0 {
+			continue
+		}
+		line++
+
+		if !json.Valid(doc) {
+			return fmt.Errorf("invalid JSON document on line [%d] of seed data for [%s]", line, target)
+		}
+
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", line, doc)))
+		action := map[string]interface{}{
+			"create": map[string]string{
+				"_id": hex.EncodeToString(sum[:]),
+			},
+		}
+		if err := json.NewEncoder(&body).Encode(action); err != nil {
+			return fmt.Errorf("unable to encode seed data for [%s]: %w", target, err)
+		}
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read seed data for [%s]: %w", target, err)
+	}
+
+	if line == 0 {
+		return nil
+	}
+
+	res, err := conn.Bulk(&body, conn.Bulk.WithIndex(target))
+	if err != nil {
+		return fmt.Errorf("unable to load seed data into [%s]: %w", target, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	var r struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	if !r.Errors {
+		return nil
+	}
+
+	for _, item := range r.Items {
+		for _, result := range item {
+			// Documents that were loaded before already exist
+			if result.Status >= 300 && result.Status != 409 {
+				return fmt.Errorf("unable to load seed data into [%s]: %s: %s", target, result.Error.Type, result.Error.Reason)
+			}
+		}
+	}
+
+	return nil
+}
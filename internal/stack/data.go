@@ -0,0 +1,90 @@
+package stack
+
+import (
+	"fmt"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+func PutILMPolicy(conn *es.Client, name string, policy interface{}) error {
+	body, err := encodeBody(policy)
+	if err != nil {
+		return fmt.Errorf("unable to put ILM policy [%s]: %w", name, err)
+	}
+
+	res, err := conn.ILM.PutLifecycle(name, conn.ILM.PutLifecycle.WithBody(body))
+	if err != nil {
+		return fmt.Errorf("unable to put ILM policy [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
+
+func PutIndexTemplate(conn *es.Client, name string, template interface{}) error {
+	body, err := encodeBody(template)
+	if err != nil {
+		return fmt.Errorf("unable to put index template [%s]: %w", name, err)
+	}
+
+	res, err := conn.Indices.PutIndexTemplate(name, body)
+	if err != nil {
+		return fmt.Errorf("unable to put index template [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
+
+func EnsureDataStream(conn *es.Client, name string) error {
+	res, err := conn.Indices.CreateDataStream(name)
+	if err != nil {
+		return fmt.Errorf("unable to create data stream [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == 400 {
+			typ, err := errorType(res)
+			if err != nil {
+				return fmt.Errorf("unable to create data stream [%s]: %w", name, err)
+			}
+			if typ == "resource_already_exists_exception" {
+				return nil
+			}
+			return fmt.Errorf("unable to create data stream [%s]: [%s] %s", name, res.Status(), typ)
+		}
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
+
+func PutSLMPolicy(conn *es.Client, name string, policy interface{}) error {
+	body, err := encodeBody(policy)
+	if err != nil {
+		return fmt.Errorf("unable to put SLM policy [%s]: %w", name, err)
+	}
+
+	res, err := conn.SlmPutLifecycle(name, conn.SlmPutLifecycle.WithBody(body))
+	if err != nil {
+		return fmt.Errorf("unable to put SLM policy [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return esutil.ResponseError(res)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package stack
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+)
+
+// CreateAPIKey creates an API key with the given name, limited to the given role
+// descriptors, and returns its base64-encoded ID and key, as expected in the
+// Authorization header.
+func CreateAPIKey(conn *es.Client, name string, roleDescriptors map[string]interface{}) (string, error) {
+	body, err := encodeBody(map[string]interface{}{
+		"name":             name,
+		"role_descriptors": roleDescriptors,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	res, err := conn.Security.CreateAPIKey(body)
+	if err != nil {
+		return "", fmt.Errorf("unable to create API key [%s]: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", esutil.ResponseError(res)
+	}
+
+	var r struct {
+		ID     string `json:"id"`
+		APIKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(r.ID + ":" + r.APIKey)), nil
+}
@@ -0,0 +1,34 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+func encodeBody(body interface{}) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("unable to encode body as JSON: %w", err)
+	}
+
+	return &buf, nil
+}
+
+// errorType returns the type of the Elasticsearch error in the given response
+// body, if any.
+func errorType(res *esapi.Response) (string, error) {
+	var e struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
+		return "", fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return e.Error.Type, nil
+}
@@ -62,6 +62,21 @@ func NewConnection(address, username, password string) (*Connection, error) {
 	return connectionSingleton, nil
 }
 
+// Ping returns an error if the usage cluster cannot be reached.
+func (c *Connection) Ping() error {
+	res, err := c.esClient.Ping()
+	if err != nil {
+		return fmt.Errorf("unable to reach usage cluster: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to reach usage cluster: [%s]", res.Status())
+	}
+
+	return nil
+}
+
 func (c *Connection) GetInstanceCapacityGBHours(q Query) (float64, error) {
 	// TODO: refactor to PG package as this will need a PostgresQL connection and query
 	return 0, nil
@@ -0,0 +1,114 @@
+package watches
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	es "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/esutil"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+type Installer struct {
+	stateConn *es.Client
+	cfg       config.ValidationFailuresWatch
+}
+
+func NewInstaller(stateConn *es.Client, cfg config.ValidationFailuresWatch) *Installer {
+	return &Installer{
+		stateConn: stateConn,
+		cfg:       cfg,
+	}
+}
+
+// Apply installs the scenario's watch, unless the installed watch is stamped
+// with the same hash.
+func (i *Installer) Apply(scenarioID string) error {
+	id := ValidationFailuresID(scenarioID)
+
+	watch := validationFailures(i.cfg, scenarioID)
+	body, err := json.Marshal(watch)
+	if err != nil {
+		return fmt.Errorf("unable to encode watch [%s] as JSON: %w", id, err)
+	}
+	hash := models.Hash(body)
+
+	installed, err := i.installedHash(id)
+	if err != nil {
+		return err
+	}
+	if installed == hash {
+		return nil
+	}
+
+	watch["metadata"].(map[string]interface{})["hash"] = hash
+	body, err = json.Marshal(watch)
+	if err != nil {
+		return fmt.Errorf("unable to encode watch [%s] as JSON: %w", id, err)
+	}
+
+	res, err := i.stateConn.Watcher.PutWatch(id, i.stateConn.Watcher.PutWatch.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return fmt.Errorf("unable to put watch [%s]: %w", id, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("unable to put watch [%s]: %w", id, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func (i *Installer) Delete(scenarioID string) error {
+	id := ValidationFailuresID(scenarioID)
+
+	res, err := i.stateConn.Watcher.DeleteWatch(id)
+	if err != nil {
+		return fmt.Errorf("unable to delete watch [%s]: %w", id, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("unable to delete watch [%s]: %w", id, esutil.ResponseError(res))
+	}
+
+	return nil
+}
+
+func (i *Installer) installedHash(id string) (string, error) {
+	res, err := i.stateConn.Watcher.GetWatch(id)
+	if err != nil {
+		return "", fmt.Errorf("unable to get watch [%s]: %w", id, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+
+	if res.IsError() {
+		return "", fmt.Errorf("unable to get watch [%s]: %w", id, esutil.ResponseError(res))
+	}
+
+	var r struct {
+		Watch struct {
+			Metadata struct {
+				Hash string `json:"hash"`
+			} `json:"metadata"`
+		} `json:"watch"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	return r.Watch.Metadata.Hash, nil
+}
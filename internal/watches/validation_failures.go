@@ -0,0 +1,218 @@
+// Package watches renders and installs the validation failures watches.
+package watches
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/config"
+	"github.com/ycombinator/cloud-billing-golden-deployment/internal/models"
+)
+
+const ValidationFailures = "gds-validation-failures"
+
+const validationResultsIndex = "gds-validation-results"
+
+const maxReported = 100
+
+func ValidationFailuresID(scenarioID string) string {
+	if scenarioID == "" {
+		return ValidationFailures
+	}
+
+	return ValidationFailures + "-" + scenarioID
+}
+
+func Metrics() []string {
+	results := (&models.ValidationResult{}).Metrics()
+
+	metrics := make([]string, 0, len(results))
+	for metric := range results {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	return metrics
+}
+
+func RenderValidationFailures(cfg config.ValidationFailuresWatch, scenarioID string) ([]byte, error) {
+	rendered, err := json.MarshalIndent(validationFailures(cfg, scenarioID), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode watch [%s] as JSON: %w", ValidationFailuresID(scenarioID), err)
+	}
+
+	return append(rendered, '\n'), nil
+}
+
+func validationFailures(cfg config.ValidationFailuresWatch, scenarioID string) map[string]interface{} {
+	metrics := Metrics()
+	interval := cfg.GetInterval()
+
+	filters := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"@timestamp": map[string]string{
+					"gte": "now-" + interval,
+					"lte": "now",
+				},
+			},
+		},
+	}
+	if scenarioID != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]string{"scenario_id": scenarioID},
+		})
+	}
+
+	failures := make([]interface{}, 0, len(metrics))
+	metricAggs := make(map[string]interface{}, len(metrics))
+	for _, metric := range metrics {
+		failures = append(failures, failed(metric))
+		metricAggs[metric] = map[string]interface{}{"filter": failed(metric)}
+	}
+	filters = append(filters, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               failures,
+			"minimum_should_match": 1,
+		},
+	})
+
+	metadata := map[string]interface{}{
+		"metrics": metrics,
+	}
+	if scenarioID != "" {
+		metadata["scenario_id"] = scenarioID
+	}
+
+	return map[string]interface{}{
+		"metadata": metadata,
+		"trigger": map[string]interface{}{
+			"schedule": map[string]string{"interval": interval},
+		},
+		"input": map[string]interface{}{
+			"search": map[string]interface{}{
+				"request": map[string]interface{}{
+					"indices": []string{validationResultsIndex},
+					"body": map[string]interface{}{
+						"size": maxReported,
+						"sort": []interface{}{
+							map[string]string{"@timestamp": "desc"},
+						},
+						"query": map[string]interface{}{
+							"bool": map[string]interface{}{"filter": filters},
+						},
+						"aggs": map[string]interface{}{
+							"scenarios": map[string]interface{}{
+								"terms": map[string]interface{}{
+									"field": "scenario_id",
+									"size":  maxReported,
+								},
+								"aggs": metricAggs,
+							},
+						},
+					},
+				},
+			},
+		},
+		"condition": map[string]interface{}{
+			"compare": map[string]interface{}{
+				"ctx.payload.hits.total": map[string]int{"gt": 0},
+			},
+		},
+		"actions": actions(cfg, metrics),
+	}
+}
+
+func failed(metric string) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				map[string]interface{}{
+					"term": map[string]bool{metric + ".is_valid": false},
+				},
+			},
+			"must_not": []interface{}{
+				map[string]interface{}{
+					"exists": map[string]string{"field": metric + ".error"},
+				},
+			},
+		},
+	}
+}
+
+func actions(cfg config.ValidationFailuresWatch, metrics []string) map[string]interface{} {
+	summary := summary(cfg.GetInterval(), metrics)
+	if len(cfg.Actions) == 0 {
+		return map[string]interface{}{
+			"log": map[string]interface{}{
+				"logging": map[string]string{"text": summary},
+			},
+		}
+	}
+
+	actions := make(map[string]interface{}, len(cfg.Actions))
+	for _, a := range cfg.Actions {
+		switch a.Type {
+		case config.WatchActionTypeIndex:
+			actions[a.Name] = map[string]interface{}{
+				"transform": map[string]interface{}{
+					"script": map[string]string{
+						"source": "def docs = []; " +
+							"for (hit in ctx.payload.hits.hits) { " +
+							"def doc = new HashMap(hit._source); " +
+							"doc['watch_id'] = ctx.watch_id; " +
+							"doc['triggered_time'] = ctx.trigger.triggered_time; " +
+							"docs.add(doc); " +
+							"} " +
+							"return ['_doc': docs];",
+					},
+				},
+				"index": map[string]string{"index": a.Index},
+			}
+
+		case config.WatchActionTypeWebhook:
+			headers := map[string]string{"Content-Type": "application/json"}
+			for name, value := range a.Headers {
+				headers[name] = value
+			}
+
+			actions[a.Name] = map[string]interface{}{
+				"webhook": map[string]interface{}{
+					"method":  "post",
+					"url":     a.URL,
+					"headers": headers,
+					"body": `{"watch_id": "{{ctx.watch_id}}", "triggered_time": "{{ctx.trigger.triggered_time}}", ` +
+						`"failures": {{#toJson}}ctx.payload.hits.hits{{/toJson}}, ` +
+						`"scenarios": {{#toJson}}ctx.payload.aggregations.scenarios.buckets{{/toJson}}}`,
+				},
+			}
+
+		case config.WatchActionTypeEmail:
+			actions[a.Name] = map[string]interface{}{
+				"email": map[string]interface{}{
+					"to":      a.To,
+					"subject": "Golden deployment scenarios failed validation",
+					"body": map[string]string{
+						"text": summary,
+					},
+				},
+			}
+		}
+	}
+
+	return actions
+}
+
+func summary(interval string, metrics []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "{{ctx.payload.hits.total}} validation results failed in the last %s.\n", interval)
+	b.WriteString("{{#ctx.payload.aggregations.scenarios.buckets}}\nscenario {{key}}:\n")
+	for _, metric := range metrics {
+		fmt.Fprintf(&b, "  %s: {{%s.doc_count}} failure(s)\n", metric, metric)
+	}
+	b.WriteString("{{/ctx.payload.aggregations.scenarios.buckets}}")
+
+	return b.String()
+}